@@ -1,12 +1,124 @@
 package caprice
 
 import (
+	"errors"
 	"os"
+	"reflect"
 	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
 )
 
 func TestRequests(t *testing.T) {
-	t.Skip("Don't run until I can get a decent testing facility")
+
+	server := randomtest.NewServer(randomtest.WithSeed(7))
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL))
+
+	t.Run("Basic methods", func(t *testing.T) {
+		integers, err := rng.GenerateIntegers(5, 1, 5, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := map[int]bool{}
+		for _, integer := range integers {
+			if integer < 1 || integer > 5 || seen[integer] {
+				t.Errorf("bad draw without replacement: %v", integers)
+			}
+			seen[integer] = true
+		}
+
+		fractions, err := rng.GenerateDecimalFractions(5, 4, true)
+		if err != nil || len(fractions) != 5 {
+			t.Errorf("GenerateDecimalFractions: %v, %v", fractions, err)
+		}
+		gaussians, err := rng.GenerateGaussians(5, 10, 2, 6)
+		if err != nil || len(gaussians) != 5 {
+			t.Errorf("GenerateGaussians: %v, %v", gaussians, err)
+		}
+		strings, err := rng.GenerateStrings(5, 8, "abc", true)
+		if err != nil || len(strings) != 5 || len(strings[0]) != 8 {
+			t.Errorf("GenerateStrings: %v, %v", strings, err)
+		}
+		uuids, err := rng.GenerateUUIDs(2)
+		if err != nil || len(uuids) != 2 || !uuids[0].IsV4() {
+			t.Errorf("GenerateUUIDs: %v, %v", uuids, err)
+		}
+		blobs, err := rng.GenerateBlobs(2, 8*Byte, Hex)
+		if err != nil || len(blobs) != 2 || len(blobs[0]) != 8 {
+			t.Errorf("GenerateBlobs: %v, %v", blobs, err)
+		}
+	})
+
+	t.Run("Signed methods", func(t *testing.T) {
+		integers, err := rng.GenerateSignedIntegers(5, 1, 10, true)
+		if err != nil || len(integers.Data) != 5 || integers.Signature == "" || integers.SerialNumber != 1 {
+			t.Errorf("GenerateSignedIntegers: %+v, %v", integers, err)
+		}
+		if _, err := rng.VerifySignature(integers.Raw, integers.Signature); err != nil {
+			t.Error(err)
+		}
+
+		fractions, err := rng.GenerateSignedDecimalFractions(5, 4, true)
+		if err != nil || len(fractions.Data) != 5 || fractions.SerialNumber != 2 {
+			t.Errorf("GenerateSignedDecimalFractions: %+v, %v", fractions, err)
+		}
+		gaussians, err := rng.GenerateSignedGaussians(5, 10, 2, 6)
+		if err != nil || len(gaussians.Data) != 5 {
+			t.Errorf("GenerateSignedGaussians: %+v, %v", gaussians, err)
+		}
+		strings, err := rng.GenerateSignedStrings(5, 8, "abc", true)
+		if err != nil || len(strings.Data) != 5 {
+			t.Errorf("GenerateSignedStrings: %+v, %v", strings, err)
+		}
+		uuids, err := rng.GenerateSignedUUIDs(2)
+		if err != nil || len(uuids.Data) != 2 {
+			t.Errorf("GenerateSignedUUIDs: %+v, %v", uuids, err)
+		}
+		blobs, err := rng.GenerateSignedBlobs(2, 64*Bit, Base64)
+		if err != nil || len(blobs.Data) != 2 || len(blobs.Data[0]) != 8 || blobs.HashedApiKey == "" {
+			t.Errorf("GenerateSignedBlobs: %+v, %v", blobs, err)
+		}
+	})
+
+	t.Run("Usage is accounted for", func(t *testing.T) {
+		status, err := rng.GetUsage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.RequestsLeft != randomtest.DefaultRequests-12 || status.BitsLeft >= randomtest.DefaultBits {
+			t.Errorf("unexpected usage %+v", status)
+		}
+	})
+
+	t.Run("Same seed, same data", func(t *testing.T) {
+		other := randomtest.NewServer(randomtest.WithSeed(7))
+		defer other.Close()
+		first, _ := TrueRNG("key", WithEndpoint(other.URL)).GenerateIntegers(5, 1, 5, false)
+
+		again := randomtest.NewServer(randomtest.WithSeed(7))
+		defer again.Close()
+		second, _ := TrueRNG("key", WithEndpoint(again.URL)).GenerateIntegers(5, 1, 5, false)
+
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("%v != %v", first, second)
+		}
+	})
+
+	t.Run("Injected errors are returned", func(t *testing.T) {
+		server.InjectError(403, "The operation requires 10 bits, but the API key only has 0 left")
+		if _, err := rng.GenerateIntegers(1, 1, 10, true); !errors.Is(err, ErrQuotaExhausted) {
+			t.Errorf("expected quota exhaustion, got %v", err)
+		}
+	})
+
+	t.Run("Quota is enforced", func(t *testing.T) {
+		poor := randomtest.NewServer(randomtest.WithQuota(10, 10))
+		defer poor.Close()
+		if _, err := TrueRNG("key", WithEndpoint(poor.URL)).GenerateBlobs(1, 64, "hex"); !errors.Is(err, ErrQuotaExhausted) {
+			t.Errorf("expected quota exhaustion, got %v", err)
+		}
+	})
 }
 
 func TestGenerateIntegers(t *testing.T) {
@@ -18,7 +130,7 @@ func TestGenerateIntegers(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		numbers, err := rng.GenerateIntegers(5, 1, 10, true)
 		t.Log(numbers)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -32,13 +144,13 @@ func TestGenerateSignedIntegers(t *testing.T) {
 		}
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		result, err := rng.GenerateSignedIntegers(5, 1, 10, true)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 
 		t.Run("Verify signature", func(t *testing.T) {
 			result, err := rng.VerifySignature(result.Raw, result.Signature)
-			if err.Message != "" {
+			if err != nil {
 				t.Error(err)
 			}
 			if result == false {
@@ -56,7 +168,7 @@ func TestGenerateDecimalFractions(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		numbers, err := rng.GenerateDecimalFractions(5, 10, true)
 		t.Log(numbers)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -70,7 +182,7 @@ func TestGenerateSignedDecimalFractions(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		numbers, err := rng.GenerateSignedDecimalFractions(5, 10, true)
 		t.Logf("%+v", numbers)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -84,7 +196,7 @@ func TestGenerateGaussians(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		numbers, err := rng.GenerateGaussians(10, 5, 1.4, 12)
 		t.Log(numbers)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -98,7 +210,7 @@ func TestGenerateSignedGaussians(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		numbers, err := rng.GenerateSignedGaussians(10, 5, 1.4, 12)
 		t.Logf("%+v", numbers)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -112,7 +224,7 @@ func TestGenerateStrings(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		strings, err := rng.GenerateStrings(10, 12, "ab%⌘", false)
 		t.Log(strings)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -126,7 +238,7 @@ func TestGenerateSignedStrings(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		strings, err := rng.GenerateSignedStrings(10, 12, "ab%⌘", false)
 		t.Logf("%+v", strings)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -140,7 +252,7 @@ func TestGenerateUUIDs(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		strings, err := rng.GenerateUUIDs(10)
 		t.Log(strings)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -154,7 +266,7 @@ func TestGenerateSignedUUIDs(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		strings, err := rng.GenerateSignedUUIDs(10)
 		t.Logf("%+v", strings)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -168,7 +280,7 @@ func TestGenerateBlobs(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		strings, err := rng.GenerateBlobs(10, 8, "base64")
 		t.Log(strings)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -182,7 +294,7 @@ func TestGenerateSignedBlobs(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		strings, err := rng.GenerateSignedBlobs(10, 8, "base64")
 		t.Logf("%+v", strings)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
@@ -197,7 +309,7 @@ func TestGetUsage(t *testing.T) {
 		rng := TrueRNG(os.Getenv("APIKEY"))
 		response, err := rng.GetUsage()
 		t.Logf("%+v", response)
-		if err.Message != "" {
+		if err != nil {
 			t.Error(err)
 		}
 	})
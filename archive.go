@@ -0,0 +1,274 @@
+package caprice
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Anomalies an Archive flags. Append and Audit return errors that match them with errors.Is.
+var (
+	// A serial number of an API key is missing between two archived results.
+	ErrSerialGap = errors.New("caprice: gap in serial numbers")
+	// A serial number of an API key has been archived more than once.
+	ErrDuplicateSerial = errors.New("caprice: duplicate serial number")
+	// The last line of an archive was cut off, e.g. by a crash while it was being written.
+	ErrTruncatedRecord = errors.New("caprice: truncated record")
+)
+
+// One line of an Archive file. The hashed API key and serial number are only ever read from the signed
+// `Random` object, never stored beside it, so they cannot be changed without breaking the signature.
+type ArchiveEntry struct {
+	// The `random` object exactly as received. It is kept as a string so that encoding the entry
+	// cannot alter it.
+	Random    string    `json:"random"`
+	Signature string    `json:"signature"`
+	Archived  time.Time `json:"archived"`
+}
+
+// Something Audit found wrong with an archive: an anomaly in the serial numbers, a signature that does
+// not verify or a line that cannot be read. Line counts from 1.
+type Finding struct {
+	Line         int
+	HashedApiKey string
+	SerialNumber int
+	Err          error
+}
+
+func (f Finding) Error() string {
+	return fmt.Sprintf("line %d: %v", f.Line, f.Err)
+}
+
+func (f Finding) Unwrap() error {
+	return f.Err
+}
+
+// An append-only record of signed results, kept as a JSON Lines file with one ArchiveEntry per line.
+// Each result is stored byte-for-byte with its signature, so the archive can be re-verified at any time
+// and serves as an audit trail of every draw. Safe for concurrent use; pass it to WithArchive to record
+// every signed result a client receives.
+type Archive struct {
+	mu   sync.Mutex
+	file *os.File
+	last map[string]int
+	seen map[string]map[int]bool
+}
+
+// A helper function that opens the archive at `path` for appending, creating it if needed. Existing
+// entries are read so that continuity checks carry on where they left off.
+//
+// If the last line was cut off before it was complete, it is dropped so that new entries start on a line
+// of their own, and the archive is returned along with an error matching ErrTruncatedRecord. The draw it
+// held then shows up as a gap in its serial numbers.
+func OpenArchive(path string) (*Archive, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("caprice: %w", err)
+	}
+
+	a := &Archive{file: file, last: map[string]int{}, seen: map[string]map[int]bool{}}
+	truncated := false
+	partial, err := scanArchive(file, func(_ int, entry ArchiveEntry, header Random, err error) {
+		truncated = truncated || errors.Is(err, ErrTruncatedRecord)
+		if err == nil {
+			a.record(header)
+		}
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	switch {
+	case truncated:
+		if err := file.Truncate(partial); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("caprice: cannot drop a truncated record: %w", err)
+		}
+		return a, fmt.Errorf("%w: dropped the incomplete last line of %s", ErrTruncatedRecord, path)
+	case partial >= 0:
+		// the last entry is whole but lost its newline
+		if _, err := file.Write([]byte{'\n'}); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("caprice: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// Archive a signed result. The entry is always written; if it breaks the run of serial numbers of its
+// API key, the error returned says so and matches ErrSerialGap or ErrDuplicateSerial.
+func (a *Archive) Append(data SignedData) error {
+	random, signature := data.payload()
+	return a.AppendRaw(random, signature)
+}
+
+// Same as Append, for a `random` object and `signature` as RANDOM.org returned them.
+func (a *Archive) AppendRaw(random json.RawMessage, signature string) error {
+
+	var header Random
+	if err := json.Unmarshal(random, &header); err != nil {
+		return fmt.Errorf("%w: cannot archive %s: %w", ErrMalformedResponse, random, err)
+	}
+	entry := ArchiveEntry{Random: string(random), Signature: signature, Archived: time.Now().UTC()}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("caprice: cannot archive: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("caprice: cannot archive: %w", err)
+	}
+	return a.record(header)
+}
+
+// Notes the result `header` belongs to for continuity checks, reporting what is wrong with it. Callers
+// hold a.mu or own a.
+func (a *Archive) record(header Random) error {
+	key, serial := header.HashedApiKey, header.SerialNumber
+	if a.seen[key] == nil {
+		a.seen[key] = map[int]bool{}
+	}
+	duplicate := a.seen[key][serial]
+	a.seen[key][serial] = true
+	last, known := a.last[key]
+	if serial > last {
+		a.last[key] = serial
+	}
+
+	switch {
+	case duplicate:
+		return fmt.Errorf("%w: %d", ErrDuplicateSerial, serial)
+	case known && serial > last+1:
+		return fmt.Errorf("%w: %d follows %d", ErrSerialGap, serial, last)
+	}
+	return nil
+}
+
+// Flushes the archive to disk and closes it.
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.file.Sync(); err != nil {
+		a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
+
+// Re-reads the archive file at `path` and checks every entry: that its signature verifies with
+// `verifier`, and that the serial numbers of each API key run without gaps or duplicates. Only results
+// whose signature verifies count towards continuity, so a forged line cannot fill a gap. A last line
+// that was cut off is reported as ErrTruncatedRecord rather than ErrMalformedResponse. The error is
+// only set if the file cannot be read at all, or `verifier` has no key (ErrNoPublicKey).
+func Audit(path string, verifier Verifier) ([]Finding, error) {
+	if verifier.key == nil {
+		return nil, fmt.Errorf("%w: cannot audit without one", ErrNoPublicKey)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("caprice: %w", err)
+	}
+	defer file.Close()
+
+	var findings []Finding
+	lines := map[string]map[int][]int{}
+	_, err = scanArchive(file, func(line int, entry ArchiveEntry, header Random, err error) {
+		if err != nil {
+			findings = append(findings, Finding{Line: line, Err: err})
+			return
+		}
+		key, serial := header.HashedApiKey, header.SerialNumber
+		if _, err := verifier.VerifyRaw(json.RawMessage(entry.Random), entry.Signature); err != nil {
+			findings = append(findings, Finding{Line: line, HashedApiKey: key, SerialNumber: serial, Err: err})
+			return
+		}
+		if lines[key] == nil {
+			lines[key] = map[int][]int{}
+		}
+		lines[key][serial] = append(lines[key][serial], line)
+	})
+	if err != nil {
+		return findings, err
+	}
+
+	for key, serials := range lines {
+		numbers := make([]int, 0, len(serials))
+		for serial := range serials {
+			numbers = append(numbers, serial)
+		}
+		sort.Ints(numbers)
+		for i, serial := range numbers {
+			for _, line := range serials[serial][1:] {
+				findings = append(findings, Finding{Line: line, HashedApiKey: key, SerialNumber: serial,
+					Err: fmt.Errorf("%w: %d, first archived on line %d", ErrDuplicateSerial, serial, serials[serial][0])})
+			}
+			if i > 0 && serial > numbers[i-1]+1 {
+				findings = append(findings, Finding{Line: serials[serial][0], HashedApiKey: key, SerialNumber: serial,
+					Err: fmt.Errorf("%w: %d follows %d", ErrSerialGap, serial, numbers[i-1])})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	return findings, nil
+}
+
+// Calls `visit` with every entry of an archive file and the header of its `random` object, or with the
+// error that made a line unreadable; a last line that is both unreadable and missing its newline was cut
+// off, and is reported as ErrTruncatedRecord. Returns the offset at which a last line missing its newline
+// starts, or -1 if the file ends with a newline.
+func scanArchive(file *os.File, visit func(line int, entry ArchiveEntry, header Random, err error)) (int64, error) {
+	scanner := bufio.NewScanner(file)
+	// a line holds at most one result, and results are at most a few hundred kilobytes
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+
+	var start, end int64
+	terminated := true
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if token != nil {
+			start, end, terminated = end, end+int64(advance), advance > len(token)
+		}
+		return advance, token, err
+	})
+
+	for line := 1; scanner.Scan(); line++ {
+		malformed := ErrMalformedResponse
+		if !terminated {
+			malformed = ErrTruncatedRecord
+		}
+		var entry ArchiveEntry
+		var header Random
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			visit(line, entry, header, fmt.Errorf("%w: %w", malformed, err))
+			continue
+		}
+		if err := json.Unmarshal([]byte(entry.Random), &header); err != nil {
+			visit(line, entry, header, fmt.Errorf("%w: %w", malformed, err))
+			continue
+		}
+		visit(line, entry, header, nil)
+	}
+	if err := scanner.Err(); err != nil {
+		return -1, fmt.Errorf("caprice: cannot read archive: %w", err)
+	}
+	if !terminated {
+		return start, nil
+	}
+	return -1, nil
+}
+
+// Append every signed result this client receives to `archive`. Problems archiving a result, including
+// gaps and duplicates in its serial numbers, are logged at warn level and never fail the call.
+func WithArchive(archive *Archive) Option {
+	return func(c *config) {
+		c.archive = archive
+	}
+}
@@ -0,0 +1,213 @@
+package caprice
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestArchive(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	verifier := NewVerifier(server.PublicKey())
+
+	t.Run("Every signed result is archived and verifies", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "draws.jsonl")
+		archive, err := OpenArchive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithArchive(archive))
+		if _, err := rng.GenerateSignedIntegers(5, 1, 10, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rng.GenerateSignedStrings(2, 4, "ab%⌘<&", true); err != nil {
+			t.Fatal(err)
+		}
+		uuids, err := rng.GenerateSignedUUIDs(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := archive.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		findings, err := Audit(path, verifier)
+		if err != nil || len(findings) != 0 {
+			t.Errorf("got %v, %v", findings, err)
+		}
+
+		// reopening carries on from the last serial number
+		archive, _ = OpenArchive(path)
+		defer archive.Close()
+		if err := archive.Append(uuids); !errors.Is(err, ErrDuplicateSerial) {
+			t.Errorf("expected a duplicate, got %v", err)
+		}
+	})
+
+	t.Run("Gaps and duplicates are flagged", func(t *testing.T) {
+		rng := TrueRNG("gappy", WithEndpoint(server.URL))
+		var draws []SignedIntegerData
+		for i := 0; i < 4; i++ {
+			draw, err := rng.GenerateSignedIntegers(1, 1, 6, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			draws = append(draws, draw)
+		}
+
+		path := filepath.Join(t.TempDir(), "draws.jsonl")
+		archive, _ := OpenArchive(path)
+		if err := archive.Append(draws[0]); err != nil {
+			t.Error(err)
+		}
+		if err := archive.Append(draws[2]); !errors.Is(err, ErrSerialGap) {
+			t.Errorf("expected a gap, got %v", err)
+		}
+		if err := archive.Append(draws[2]); !errors.Is(err, ErrDuplicateSerial) {
+			t.Errorf("expected a duplicate, got %v", err)
+		}
+		archive.Close()
+
+		findings, err := Audit(path, verifier)
+		if err != nil || len(findings) != 2 {
+			t.Fatalf("got %v, %v", findings, err)
+		}
+		if !errors.Is(findings[0], ErrSerialGap) || findings[0].Line != 2 {
+			t.Errorf("expected a gap on line 2, got %v", findings[0])
+		}
+		if !errors.Is(findings[1], ErrDuplicateSerial) || findings[1].Line != 3 {
+			t.Errorf("expected a duplicate on line 3, got %v", findings[1])
+		}
+	})
+
+	t.Run("Tampering is detected", func(t *testing.T) {
+		rng := TrueRNG("tampered", WithEndpoint(server.URL))
+		draw, _ := rng.GenerateSignedIntegers(3, 1, 6, true)
+
+		path := filepath.Join(t.TempDir(), "draws.jsonl")
+		archive, _ := OpenArchive(path)
+		archive.Append(draw)
+		archive.Close()
+
+		contents, _ := os.ReadFile(path)
+		tampered := strings.Replace(string(contents), `\"serialNumber\":`, `\"serialNumber\": `, 1)
+		os.WriteFile(path, []byte(tampered+"not json\n"), 0644)
+
+		findings, err := Audit(path, verifier)
+		if err != nil || len(findings) != 2 {
+			t.Fatalf("got %v, %v", findings, err)
+		}
+		if !errors.Is(findings[0], ErrBadSignature) {
+			t.Errorf("expected a bad signature, got %v", findings[0])
+		}
+		if !errors.Is(findings[1], ErrMalformedResponse) || findings[1].Line != 2 {
+			t.Errorf("expected an unreadable line 2, got %v", findings[1])
+		}
+	})
+
+	t.Run("A partial last record is dropped on reopening", func(t *testing.T) {
+		rng := TrueRNG("partial", WithEndpoint(server.URL))
+		var draws []SignedIntegerData
+		for i := 0; i < 4; i++ {
+			draw, err := rng.GenerateSignedIntegers(1, 1, 6, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			draws = append(draws, draw)
+		}
+
+		path := filepath.Join(t.TempDir(), "draws.jsonl")
+		archive, _ := OpenArchive(path)
+		for _, draw := range draws[:3] {
+			archive.Append(draw)
+		}
+		archive.Close()
+
+		// a crash halfway through writing the third line
+		contents, _ := os.ReadFile(path)
+		lines := strings.SplitAfter(string(contents), "\n")
+		os.WriteFile(path, []byte(lines[0]+lines[1]+lines[2][:len(lines[2])/2]), 0644)
+
+		findings, err := Audit(path, verifier)
+		if err != nil || len(findings) != 1 || !errors.Is(findings[0], ErrTruncatedRecord) || findings[0].Line != 3 {
+			t.Fatalf("expected a truncated line 3, got %v, %v", findings, err)
+		}
+
+		archive, err = OpenArchive(path)
+		if !errors.Is(err, ErrTruncatedRecord) || archive == nil {
+			t.Fatalf("got %v, %v", archive, err)
+		}
+		if err := archive.Append(draws[3]); !errors.Is(err, ErrSerialGap) {
+			t.Errorf("expected the lost draw to leave a gap, got %v", err)
+		}
+		archive.Close()
+
+		findings, err = Audit(path, verifier)
+		if err != nil || len(findings) != 1 || !errors.Is(findings[0], ErrSerialGap) || findings[0].Line != 3 {
+			t.Errorf("expected only a gap on line 3, got %v, %v", findings, err)
+		}
+	})
+
+	t.Run("A whole last record without its newline is kept", func(t *testing.T) {
+		rng := TrueRNG("unterminated", WithEndpoint(server.URL))
+		first, _ := rng.GenerateSignedIntegers(1, 1, 6, true)
+		second, _ := rng.GenerateSignedIntegers(1, 1, 6, true)
+
+		path := filepath.Join(t.TempDir(), "draws.jsonl")
+		archive, _ := OpenArchive(path)
+		archive.Append(first)
+		archive.Close()
+		contents, _ := os.ReadFile(path)
+		os.WriteFile(path, []byte(strings.TrimSuffix(string(contents), "\n")), 0644)
+
+		archive, err := OpenArchive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := archive.Append(second); err != nil {
+			t.Error(err)
+		}
+		archive.Close()
+
+		findings, err := Audit(path, verifier)
+		if err != nil || len(findings) != 0 {
+			t.Errorf("got %v, %v", findings, err)
+		}
+	})
+
+	t.Run("Deleted draws cannot be hidden", func(t *testing.T) {
+		rng := TrueRNG("deleted", WithEndpoint(server.URL))
+		path := filepath.Join(t.TempDir(), "draws.jsonl")
+		archive, _ := OpenArchive(path)
+		for i := 0; i < 3; i++ {
+			draw, _ := rng.GenerateSignedIntegers(1, 1, 6, true)
+			archive.Append(draw)
+		}
+		archive.Close()
+
+		contents, _ := os.ReadFile(path)
+		lines := strings.SplitAfter(string(contents), "\n")
+		os.WriteFile(path, []byte(lines[0]+lines[2]), 0644)
+		findings, _ := Audit(path, verifier)
+		if len(findings) != 1 || !errors.Is(findings[0], ErrSerialGap) || findings[0].Line != 2 {
+			t.Errorf("expected a gap on line 2, got %v", findings)
+		}
+
+		// relabelling the draw after the deleted one breaks its signature
+		relabelled := strings.Replace(lines[2], `\"serialNumber\":3`, `\"serialNumber\":2`, 1)
+		if relabelled == lines[2] {
+			t.Fatalf("no serial number 3 in %s", lines[2])
+		}
+		os.WriteFile(path, []byte(lines[0]+relabelled), 0644)
+		findings, _ = Audit(path, verifier)
+		if len(findings) != 1 || !errors.Is(findings[0], ErrBadSignature) || findings[0].Line != 2 {
+			t.Errorf("expected a bad signature on line 2, got %v", findings)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package caprice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestIntegerBases(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL))
+
+	for _, base := range []int{2, 8, 10, 16} {
+		integers, err := rng.GenerateIntegersInBase(20, -300, 300, true, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, value := range integers {
+			if value < -300 || value > 300 {
+				t.Errorf("base %d: %d out of range", base, value)
+			}
+		}
+	}
+
+	t.Run("Raw results keep the strings", func(t *testing.T) {
+		result, err := rng.GenerateIntegersInBaseRaw(5, 16, 255, true, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, value := range result.Random.Data {
+			if text, ok := value.(string); !ok || len(text) != 2 {
+				t.Errorf("expected two hex digits, got %v", value)
+			}
+		}
+	})
+
+	t.Run("Signed results still verify", func(t *testing.T) {
+		signed, err := rng.GenerateSignedIntegersInBase(5, 0, 1, true, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := NewVerifier(server.PublicKey()).Verify(signed); !ok || err != nil {
+			t.Errorf("did not verify: %v", err)
+		}
+		if len(signed.Data) != 5 {
+			t.Errorf("got %v", signed.Data)
+		}
+	})
+
+	t.Run("Batches decode them too", func(t *testing.T) {
+		batch := rng.NewBatch()
+		call := batch.GenerateIntegersInBase(3, 100, 200, false, 8)
+		if err := batch.Do(); err != nil {
+			t.Fatal(err)
+		}
+		if integers, err := call.Integers(); err != nil || len(integers) != 3 || integers[0] < 100 {
+			t.Errorf("got %v, %v", integers, err)
+		}
+	})
+
+	t.Run("Malformed base 10 data is an error, not a panic", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"random":{"data":[true],"serialNumber":1},"signature":"",` +
+				`"bitsUsed":1,"bitsLeft":1,"requestsLeft":1,"advisoryDelay":0}}`))
+		}))
+		defer server.Close()
+		rng := TrueRNG("key", WithEndpoint(server.URL))
+
+		if _, err := rng.GenerateIntegers(1, 0, 1, true); !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("got %v", err)
+		}
+		if _, err := rng.GenerateSignedIntegers(1, 0, 1, true); !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("Other bases are refused", func(t *testing.T) {
+		if _, err := rng.GenerateIntegersInBase(1, 0, 9, true, 3); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("got %v", err)
+		}
+	})
+}
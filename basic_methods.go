@@ -1,33 +1,125 @@
 package caprice
 
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
 // Generate `n` random integers between `min` and `max`.
 // If `replacement` is true, pick random numbers with replacement. Default is false.
-// We do not support base selection, since it is easy to format into the base of your choice from base 10
-func (rng trueRNG) GenerateIntegers(n, min, max int, replacement bool) ([]int, Error) {
+// To have RANDOM.org draw them in another base, see GenerateIntegersInBase.
+func (rng trueRNG) GenerateIntegers(n, min, max int, replacement bool) ([]int, error) {
+	return rng.GenerateIntegersContext(context.Background(), n, min, max, replacement)
+}
 
-	result, err := rng.GenerateIntegersRaw(n, min, max, replacement)
+// Same as GenerateIntegers, but bound to `ctx`.
+func (rng trueRNG) GenerateIntegersContext(ctx context.Context, n, min, max int, replacement bool) ([]int, error) {
 
-	if err.Message != "" {
+	result, err := rng.GenerateIntegersRawContext(ctx, n, min, max, replacement)
+
+	if err != nil {
 		return []int{}, err
 	}
 
 	data, _ := result.Content().([]interface{})
+	return decodeIntegers(data, 10)
+}
+
+// Generate `n` random integers between `min` and `max`, drawn in `base` (2, 8, 10 or 16). RANDOM.org
+// returns them as strings in that base; they are decoded back into integers here.
+// If `replacement` is true, pick random numbers with replacement.
+func (rng trueRNG) GenerateIntegersInBase(n, min, max int, replacement bool, base int) ([]int, error) {
+	return rng.GenerateIntegersInBaseContext(context.Background(), n, min, max, replacement, base)
+}
+
+// Same as GenerateIntegersInBase, but bound to `ctx`.
+func (rng trueRNG) GenerateIntegersInBaseContext(ctx context.Context, n, min, max int, replacement bool, base int) ([]int, error) {
 
+	result, err := rng.GenerateIntegersInBaseRawContext(ctx, n, min, max, replacement, base)
+
+	if err != nil {
+		return []int{}, err
+	}
+
+	data, _ := result.Content().([]interface{})
+	return decodeIntegers(data, base)
+}
+
+// A helper function that turns the data of a generateIntegers result back into integers: numbers in base
+// 10, strings in `base` otherwise.
+func decodeIntegers(data []interface{}, base int) ([]int, error) {
 	intArray := make([]int, len(data))
 	for i, num := range data {
-		intArray[i] = int(num.(float64))
+		switch num := num.(type) {
+		case float64:
+			intArray[i] = int(num)
+		case string:
+			number, err := strconv.ParseInt(num, base, 64)
+			if err != nil {
+				return []int{}, fmt.Errorf("%w: %q is not a base %d integer", ErrMalformedResponse, num, base)
+			}
+			intArray[i] = int(number)
+		default:
+			return []int{}, fmt.Errorf("%w: %v is not an integer", ErrMalformedResponse, num)
+		}
+	}
+	return intArray, nil
+}
+
+// Generate one sequence of random integers per entry of `lengths`: sequence `i` holds lengths[i] integers
+// between mins[i] and maxes[i], picked with replacement if replacement[i] is true and drawn in bases[i].
+// Pass nil `bases` for base 10 throughout.
+func (rng trueRNG) GenerateIntegerSequences(lengths, mins, maxes []int, replacement []bool, bases []int) ([][]int, error) {
+	return rng.GenerateIntegerSequencesContext(context.Background(), lengths, mins, maxes, replacement, bases)
+}
+
+// Same as GenerateIntegerSequences, but bound to `ctx`.
+func (rng trueRNG) GenerateIntegerSequencesContext(ctx context.Context, lengths, mins, maxes []int, replacement []bool, bases []int) ([][]int, error) {
+
+	result, err := rng.GenerateIntegerSequencesRawContext(ctx, lengths, mins, maxes, replacement, bases)
+
+	if err != nil {
+		return [][]int{}, err
 	}
 
-	return intArray, Error{}
+	data, _ := result.Content().([]interface{})
+	return decodeIntegerSequences(data, bases)
+}
+
+// A helper function that decodes the data of a generateIntegerSequences result, sequence `i` from bases[i].
+func decodeIntegerSequences(data []interface{}, bases []int) ([][]int, error) {
+	sequences := make([][]int, len(data))
+	for i, sequence := range data {
+		values, ok := sequence.([]interface{})
+		if !ok {
+			return [][]int{}, fmt.Errorf("%w: %v is not a sequence", ErrMalformedResponse, sequence)
+		}
+		base := 10
+		if i < len(bases) && bases[i] != 0 {
+			base = bases[i]
+		}
+		decoded, err := decodeIntegers(values, base)
+		if err != nil {
+			return [][]int{}, err
+		}
+		sequences[i] = decoded
+	}
+	return sequences, nil
 }
 
 // Generate `n` random decimal fractions with precision upto `decimalPlaces`.
 // If `replacement` is true, pick random numbers with replacement. Default is false.
-func (rng trueRNG) GenerateDecimalFractions(n, decimalPlaces int, replacement bool) ([]float64, Error) {
+func (rng trueRNG) GenerateDecimalFractions(n, decimalPlaces int, replacement bool) ([]float64, error) {
+	return rng.GenerateDecimalFractionsContext(context.Background(), n, decimalPlaces, replacement)
+}
+
+// Same as GenerateDecimalFractions, but bound to `ctx`.
+func (rng trueRNG) GenerateDecimalFractionsContext(ctx context.Context, n, decimalPlaces int, replacement bool) ([]float64, error) {
 
-	result, err := rng.GenerateDecimalFractionsRaw(n, decimalPlaces, replacement)
+	result, err := rng.GenerateDecimalFractionsRawContext(ctx, n, decimalPlaces, replacement)
 
-	if err.Message != "" {
+	if err != nil {
 		return []float64{}, err
 	}
 
@@ -38,16 +130,21 @@ func (rng trueRNG) GenerateDecimalFractions(n, decimalPlaces int, replacement bo
 		floatArray[i] = float64(num.(float64))
 	}
 
-	return floatArray, Error{}
+	return floatArray, nil
 }
 
 // Generate `n` Gaussians from a disribution with mean `mean` and stdev `standardDeviation`, returned with
 // at most `significantDigits` sig. digits.
-func (rng trueRNG) GenerateGaussians(n int, mean, standardDeviation float64, significantDigits int) ([]float64, Error) {
+func (rng trueRNG) GenerateGaussians(n int, mean, standardDeviation float64, significantDigits int) ([]float64, error) {
+	return rng.GenerateGaussiansContext(context.Background(), n, mean, standardDeviation, significantDigits)
+}
+
+// Same as GenerateGaussians, but bound to `ctx`.
+func (rng trueRNG) GenerateGaussiansContext(ctx context.Context, n int, mean, standardDeviation float64, significantDigits int) ([]float64, error) {
 
-	result, err := rng.GenerateGaussiansRaw(n, mean, standardDeviation, significantDigits)
+	result, err := rng.GenerateGaussiansRawContext(ctx, n, mean, standardDeviation, significantDigits)
 
-	if err.Message != "" {
+	if err != nil {
 		return []float64{}, err
 	}
 
@@ -58,16 +155,21 @@ func (rng trueRNG) GenerateGaussians(n int, mean, standardDeviation float64, sig
 		floatArray[i] = float64(num.(float64))
 	}
 
-	return floatArray, Error{}
+	return floatArray, nil
 }
 
 // Generate `n` random strings with precision upto `decimalPlaces`.
 // If `replacement` is true, pick random numbers with replacement. Default is false.
-func (rng trueRNG) GenerateStrings(n, length int, characters string, replacement bool) ([]string, Error) {
+func (rng trueRNG) GenerateStrings(n, length int, characters string, replacement bool) ([]string, error) {
+	return rng.GenerateStringsContext(context.Background(), n, length, characters, replacement)
+}
+
+// Same as GenerateStrings, but bound to `ctx`.
+func (rng trueRNG) GenerateStringsContext(ctx context.Context, n, length int, characters string, replacement bool) ([]string, error) {
 
-	result, err := rng.GenerateStringsRaw(n, length, characters, replacement)
+	result, err := rng.GenerateStringsRawContext(ctx, n, length, characters, replacement)
 
-	if err.Message != "" {
+	if err != nil {
 		return []string{}, err
 	}
 
@@ -78,52 +180,56 @@ func (rng trueRNG) GenerateStrings(n, length int, characters string, replacement
 		stringArray[i] = string(string_.(string))
 	}
 
-	return stringArray, Error{}
+	return stringArray, nil
 }
 
-// Generate `n` random strings with precision upto `decimalPlaces`.
-func (rng trueRNG) GenerateUUIDs(n int) ([]string, Error) {
+// Generate `n` random version 4 UUIDs. Every value received is checked to be one.
+func (rng trueRNG) GenerateUUIDs(n int) ([]UUID, error) {
+	return rng.GenerateUUIDsContext(context.Background(), n)
+}
 
-	result, err := rng.GenerateUUIDsRaw(n)
+// Same as GenerateUUIDs, but bound to `ctx`.
+func (rng trueRNG) GenerateUUIDsContext(ctx context.Context, n int) ([]UUID, error) {
 
-	if err.Message != "" {
-		return []string{}, err
+	result, err := rng.GenerateUUIDsRawContext(ctx, n)
+
+	if err != nil {
+		return []UUID{}, err
 	}
 
 	data, _ := result.Content().([]interface{})
+	return decodeUUIDs(data)
+}
 
-	stringArray := make([]string, len(data))
-	for i, string_ := range data {
-		stringArray[i] = string(string_.(string))
-	}
-
-	return stringArray, Error{}
+// Generate `n` random blobs of `size`, e.g. 16*Byte, sent over the wire in `format` (Base64 or Hex)
+// and decoded into bytes.
+func (rng trueRNG) GenerateBlobs(n int, size BlobSize, format BlobFormat) ([][]byte, error) {
+	return rng.GenerateBlobsContext(context.Background(), n, size, format)
 }
 
-// Generate `n` random blobs of length `size`, formatted in `format` (either base64 or hex)
-func (rng trueRNG) GenerateBlobs(n, size int, format string) ([]string, Error) {
+// Same as GenerateBlobs, but bound to `ctx`.
+func (rng trueRNG) GenerateBlobsContext(ctx context.Context, n int, size BlobSize, format BlobFormat) ([][]byte, error) {
 
-	result, err := rng.GenerateBlobsRaw(n, size, format)
+	result, err := rng.GenerateBlobsRawContext(ctx, n, size, format)
 
-	if err.Message != "" {
-		return []string{}, err
+	if err != nil {
+		return [][]byte{}, err
 	}
 
 	data, _ := result.Content().([]interface{})
-
-	stringArray := make([]string, len(data))
-	for i, string_ := range data {
-		stringArray[i] = string(string_.(string))
-	}
-
-	return stringArray, Error{}
+	return decodeBlobs(data, format)
 }
 
 // Get information about current usage as a formatted Status struct.
-func (rng trueRNG) GetUsage() (Status, Error) {
+func (rng trueRNG) GetUsage() (Status, error) {
+	return rng.GetUsageContext(context.Background())
+}
+
+// Same as GetUsage, but bound to `ctx`.
+func (rng trueRNG) GetUsageContext(ctx context.Context) (Status, error) {
 	body := StatusReq{ApiKey: rng.apiKey}
-	status, err := Request("getUsage", body)
-	if err.Message != "" {
+	status, err := rng.RequestContext(ctx, "getUsage", body)
+	if err != nil {
 		return Status{}, err
 	}
 	return status.Content().(Status), err
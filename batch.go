@@ -0,0 +1,364 @@
+package caprice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Queues several calls to send to RANDOM.org as a single JSON-RPC 2.0 batch, i.e. one HTTP round trip.
+// Queue calls with the methods named after their trueRNG counterparts, send them with Do, then read each
+// outcome from the Call returned when it was queued:
+//
+//	batch := rng.NewBatch()
+//	integers := batch.GenerateIntegers(6, 1, 49, false)
+//	usage := batch.GetUsage()
+//	if err := batch.Do(); err != nil { ... }
+//	draw, err := integers.Integers()
+//
+// If the server rejects the batch as a whole, Do falls back to sending the calls one after another, so
+// a batch always behaves like the equivalent sequence of calls, only faster where batches are supported.
+type Batch struct {
+	rng   trueRNG
+	calls []*Call
+}
+
+// One call queued on a Batch. Its result or error is available once the Batch is done.
+type Call struct {
+	Method string
+	Params interface{}
+
+	id     int
+	result json.RawMessage
+	err    error
+	done   bool
+}
+
+// A helper function that returns an empty Batch sent through this client.
+func (rng trueRNG) NewBatch() *Batch {
+	return &Batch{rng: rng}
+}
+
+// Queue a call to any `method` with `params`.
+func (b *Batch) Add(method string, params interface{}) *Call {
+	call := &Call{Method: method, Params: params, id: len(b.calls) + 1}
+	b.calls = append(b.calls, call)
+	return call
+}
+
+// Queue a generateIntegers call; read it with Call.Integers.
+func (b *Batch) GenerateIntegers(n, min, max int, replacement bool) *Call {
+	return b.Add("generateIntegers", IntegersReq{ApiKey: b.rng.apiKey, N: n, Min: min, Max: max,
+		Replacement: replacement, Release4Params: b.rng.release4Params()})
+}
+
+// Queue a generateIntegers call in `base`; read it with Call.Integers.
+func (b *Batch) GenerateIntegersInBase(n, min, max int, replacement bool, base int) *Call {
+	return b.Add("generateIntegers", IntegersReq{ApiKey: b.rng.apiKey, N: n, Min: min, Max: max,
+		Replacement: replacement, Base: base, Release4Params: b.rng.release4Params()})
+}
+
+// Queue a generateIntegerSequences call; read it with Call.IntegerSequences.
+func (b *Batch) GenerateIntegerSequences(lengths, mins, maxes []int, replacement []bool, bases []int) *Call {
+	return b.Add("generateIntegerSequences", IntegerSequencesReq{ApiKey: b.rng.apiKey, N: len(lengths), Length: lengths,
+		Min: mins, Max: maxes, Replacement: replacement, Base: bases, Release4Params: b.rng.release4Params()})
+}
+
+// Queue a generateDecimalFractions call; read it with Call.Floats.
+func (b *Batch) GenerateDecimalFractions(n, decimalPlaces int, replacement bool) *Call {
+	return b.Add("generateDecimalFractions", DecimalFractionsReq{ApiKey: b.rng.apiKey, N: n,
+		DecimalPlaces: decimalPlaces, Replacement: replacement, Release4Params: b.rng.release4Params()})
+}
+
+// Queue a generateGaussians call; read it with Call.Floats.
+func (b *Batch) GenerateGaussians(n int, mean, standardDeviation float64, significantDigits int) *Call {
+	return b.Add("generateGaussians", GaussiansReq{ApiKey: b.rng.apiKey, N: n, Mean: mean,
+		StandardDeviation: standardDeviation, SignificantDigits: significantDigits, Release4Params: b.rng.release4Params()})
+}
+
+// Queue a generateStrings call; read it with Call.Strings.
+func (b *Batch) GenerateStrings(n, length int, characters string, replacement bool) *Call {
+	return b.Add("generateStrings", StringsReq{ApiKey: b.rng.apiKey, N: n, Length: length,
+		Characters: characters, Replacement: replacement, Release4Params: b.rng.release4Params()})
+}
+
+// Queue a generateUUIDs call; read it with Call.UUIDs.
+func (b *Batch) GenerateUUIDs(n int) *Call {
+	return b.Add("generateUUIDs", UUIDsReq{ApiKey: b.rng.apiKey, N: n, Release4Params: b.rng.release4Params()})
+}
+
+// Queue a generateBlobs call; read it with Call.Blobs.
+func (b *Batch) GenerateBlobs(n int, size BlobSize, format BlobFormat) *Call {
+	return b.Add("generateBlobs", BlobsReq{ApiKey: b.rng.apiKey, N: n, Size: size, Format: format,
+		Release4Params: b.rng.release4Params()})
+}
+
+// Queue a getUsage call; read it with Call.Status.
+func (b *Batch) GetUsage() *Call {
+	return b.Add("getUsage", StatusReq{ApiKey: b.rng.apiKey})
+}
+
+// Send every queued call. The returned error only reports failures of the batch as a whole; each call's
+// own outcome is on its Call. The batch waits for, and sets, the advisory delay like a single call would.
+func (b *Batch) Do() error {
+	return b.DoContext(context.Background())
+}
+
+// Same as Do, but bound to `ctx`.
+func (b *Batch) DoContext(ctx context.Context) error {
+
+	var pending []*Call
+	for _, call := range b.calls {
+		if call.done {
+			continue
+		}
+		if err := b.rng.checkRelease(call.Method, call.Params); err != nil {
+			call.finish(nil, err)
+			continue
+		}
+		if err := validate(call.Params); err != nil {
+			call.finish(nil, err)
+			continue
+		}
+		pending = append(pending, call)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	params := make([]interface{}, len(pending))
+	for i, call := range pending {
+		params[i] = call.Params
+	}
+	if err := b.rng.checkBudget(ctx, params...); err != nil {
+		for _, call := range pending {
+			call.finish(nil, err)
+		}
+		return err
+	}
+
+	once := false
+	for _, call := range pending {
+		once = once || unrepeatable(call.Method)
+	}
+	err := b.rng.retrying(ctx, once, func() error {
+		return b.attempt(ctx, pending)
+	})
+	if err != nil {
+		// nothing was settled: give back what the calls reserved, if only to reserve it again one by one
+		b.rng.quota.release(params...)
+	}
+	if !errors.Is(err, errBatchRejected) {
+		return err
+	}
+
+	// the server doesn't do batches: fall back to one call at a time
+	for _, call := range pending {
+		response, err := b.rng._request(ctx, call.Method, call.Params)
+		call.finish(response.Result, err)
+		b.settle(ctx, call)
+	}
+	return nil
+}
+
+// Does for a finished call what SignedRequestContext does for a single one: archives a signed result.
+func (b *Batch) settle(ctx context.Context, call *Call) {
+	if call.err != nil {
+		return
+	}
+	var result SignedResult
+	if signedDraw(call.Method) && decodeResult(call.result, &result) == nil {
+		b.rng.archiveResult(ctx, call.Method, result)
+	}
+}
+
+// Returned by attempt when the server answers a batch with anything other than an array of responses.
+var errBatchRejected = errors.New("caprice: batch rejected")
+
+// Sends `calls` as one scheduled batch and hands each its response.
+func (b *Batch) attempt(ctx context.Context, calls []*Call) error {
+
+	shells := make([]RequestShell, len(calls))
+	for i, call := range calls {
+		shells[i] = RequestShell{Version: "2.0", Params: call.Params, Method: call.Method, Id: call.id}
+	}
+	body, err := json.Marshal(shells)
+	if err != nil {
+		return fmt.Errorf("caprice: cannot encode batch: %w", err)
+	}
+
+	if err := b.rng.scheduler.acquire(ctx); err != nil {
+		return err
+	}
+
+	b.rng.logger.DebugContext(ctx, logRequest, "method", "batch", "calls", len(calls))
+
+	var responses []ResponseShell
+	text, err := b.rng.roundTrip(ctx, body)
+	if err == nil && json.Unmarshal(text, &responses) != nil {
+		err = errBatchRejected
+	}
+
+	// the batch counts as one request, which has to respect the longest delay any of its calls asked for
+	var delay time.Duration
+	for _, response := range responses {
+		if d := advisoryDelay(response.Result); d > delay {
+			delay = d
+		}
+	}
+	b.rng.scheduler.release(delay)
+
+	// a JSON-RPC error or a client error status in answer to the whole batch means it was not understood
+	var apiError Error
+	var status StatusError
+	if errors.As(err, &apiError) || (errors.As(err, &status) && status.StatusCode < 500) {
+		err = errBatchRejected
+	}
+	if err != nil {
+		return err
+	}
+
+	byId := map[int]ResponseShell{}
+	for _, response := range responses {
+		byId[response.Id] = response
+	}
+	for _, call := range calls {
+		response, ok := byId[call.id]
+		if !ok {
+			b.rng.quota.release(call.Params)
+			call.finish(nil, fmt.Errorf("%w: no response for call %d", ErrMalformedResponse, call.id))
+			continue
+		}
+		call.finish(response.Result, response.err())
+		if call.err != nil {
+			b.rng.quota.release(call.Params)
+			b.rng.logger.WarnContext(ctx, logError, "method", call.Method, "error", b.rng.redact(call.err))
+			continue
+		}
+		b.rng.quota.update(call.Method, call.Params, call.result)
+		b.rng.logger.DebugContext(ctx, logResponse, "method", call.Method, "result", scrubbed{call.result})
+		b.settle(ctx, call)
+	}
+	return nil
+}
+
+func (c *Call) finish(result json.RawMessage, err error) {
+	c.result, c.err, c.done = result, err, true
+}
+
+// The error this call failed with, if any.
+func (c *Call) Err() error {
+	if !c.done {
+		return fmt.Errorf("caprice: %s call has not been sent", c.Method)
+	}
+	return c.err
+}
+
+// Unmarshals the call's JSON result into `v`.
+func (c *Call) Decode(v interface{}) error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return decodeResult(c.result, v)
+}
+
+// The result of a generate call as a formatted Result struct.
+func (c *Call) Result() (Result, error) {
+	result := Result{}
+	if err := c.Decode(&result); err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// The result of a getUsage call as a formatted Status struct.
+func (c *Call) Status() (Status, error) {
+	status := Status{}
+	if err := c.Decode(&status); err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
+
+// The data of a generateIntegers call, decoded from the base it was drawn in.
+func (c *Call) Integers() ([]int, error) {
+	result, err := c.Result()
+	if err != nil {
+		return []int{}, err
+	}
+
+	base := 10
+	if request, ok := c.Params.(IntegersReq); ok && request.Base != 0 {
+		base = request.Base
+	}
+	return decodeIntegers(result.Random.Data, base)
+}
+
+// The data of a generateIntegerSequences call.
+func (c *Call) IntegerSequences() ([][]int, error) {
+	result, err := c.Result()
+	if err != nil {
+		return [][]int{}, err
+	}
+
+	request, _ := c.Params.(IntegerSequencesReq)
+	return decodeIntegerSequences(result.Random.Data, request.Base)
+}
+
+// The data of a generateDecimalFractions or generateGaussians call.
+func (c *Call) Floats() ([]float64, error) {
+	result, err := c.Result()
+	if err != nil {
+		return []float64{}, err
+	}
+
+	floatArray := make([]float64, len(result.Random.Data))
+	for i, num := range result.Random.Data {
+		number, ok := num.(float64)
+		if !ok {
+			return []float64{}, fmt.Errorf("%w: %v is not a number", ErrMalformedResponse, num)
+		}
+		floatArray[i] = number
+	}
+	return floatArray, nil
+}
+
+// The data of a generateBlobs call, decoded into bytes.
+func (c *Call) Blobs() ([][]byte, error) {
+	result, err := c.Result()
+	if err != nil {
+		return [][]byte{}, err
+	}
+
+	request, _ := c.Params.(BlobsReq)
+	return decodeBlobs(result.Random.Data, request.Format)
+}
+
+// The data of a generateUUIDs call, each checked to be a version 4 UUID.
+func (c *Call) UUIDs() ([]UUID, error) {
+	result, err := c.Result()
+	if err != nil {
+		return []UUID{}, err
+	}
+	return decodeUUIDs(result.Random.Data)
+}
+
+// The data of a generateStrings call, or the encoded data of a generateUUIDs or generateBlobs call.
+func (c *Call) Strings() ([]string, error) {
+	result, err := c.Result()
+	if err != nil {
+		return []string{}, err
+	}
+
+	stringArray := make([]string, len(result.Random.Data))
+	for i, string_ := range result.Random.Data {
+		value, ok := string_.(string)
+		if !ok {
+			return []string{}, fmt.Errorf("%w: %v is not a string", ErrMalformedResponse, string_)
+		}
+		stringArray[i] = value
+	}
+	return stringArray, nil
+}
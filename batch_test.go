@@ -0,0 +1,100 @@
+package caprice
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestBatch(t *testing.T) {
+
+	check := func(t *testing.T, server *randomtest.Server, roundTrips int) {
+		counter := &countingTransport{}
+		client := &http.Client{Transport: counter}
+		batch := TrueRNG("key", WithEndpoint(server.URL), WithHTTPClient(client)).NewBatch()
+		integers := batch.GenerateIntegers(6, 1, 49, false)
+		uuids := batch.GenerateUUIDs(2)
+		bad := batch.GenerateDecimalFractions(1, 99, true)
+		usage := batch.GetUsage()
+		if err := batch.Do(); err != nil {
+			t.Fatal(err)
+		}
+
+		if draw, err := integers.Integers(); err != nil || len(draw) != 6 {
+			t.Errorf("integers: %v, %v", draw, err)
+		}
+		if draw, err := uuids.Strings(); err != nil || len(draw) != 2 {
+			t.Errorf("uuids: %v, %v", draw, err)
+		}
+		var parameterError ParameterError
+		if _, err := bad.Floats(); !errors.As(err, &parameterError) || parameterError.Field != "decimalPlaces" {
+			t.Errorf("expected the bad call alone to fail locally, got %v", err)
+		}
+		if status, err := usage.Status(); err != nil || status.RequestsLeft != randomtest.DefaultRequests-2 {
+			t.Errorf("usage: %+v, %v", status, err)
+		}
+		if counter.requests != roundTrips {
+			t.Errorf("expected %d round trips, saw %d", roundTrips, counter.requests)
+		}
+	}
+
+	t.Run("Calls share one round trip", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		check(t, server, 1)
+	})
+
+	t.Run("Rejected batches fall back to sequential calls", func(t *testing.T) {
+		server := randomtest.NewServer(randomtest.WithoutBatches())
+		defer server.Close()
+		check(t, server, 4)
+	})
+
+	t.Run("Signed calls are archived", func(t *testing.T) {
+		for name, server := range map[string]*randomtest.Server{
+			"batched":    randomtest.NewServer(),
+			"sequential": randomtest.NewServer(randomtest.WithoutBatches()),
+		} {
+			defer server.Close()
+			path := filepath.Join(t.TempDir(), "draws.jsonl")
+			archive, err := OpenArchive(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			batch := TrueRNG("key", WithEndpoint(server.URL), WithArchive(archive)).NewBatch()
+			signed := batch.Add("generateSignedIntegers", IntegersReq{ApiKey: "key", N: 2, Min: 1, Max: 6, Replacement: true})
+			batch.GetUsage()
+			if err := batch.Do(); err != nil || signed.Err() != nil {
+				t.Fatalf("%s: %v, %v", name, err, signed.Err())
+			}
+			archive.Close()
+
+			contents, _ := os.ReadFile(path)
+			findings, err := Audit(path, NewVerifier(server.PublicKey()))
+			if lines := strings.Count(string(contents), "\n"); lines != 1 || len(findings) != 0 || err != nil {
+				t.Errorf("%s: archived %d results, found %v, %v", name, lines, findings, err)
+			}
+		}
+	})
+
+	t.Run("Unsent calls say so", func(t *testing.T) {
+		call := TrueRNG("key").NewBatch().GetUsage()
+		if call.Err() == nil {
+			t.Error("expected an error before Do")
+		}
+	})
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
@@ -0,0 +1,62 @@
+package caprice
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// The encoding RANDOM.org uses for blobs on the wire. It only matters to Raw and signed results; the
+// typed methods decode blobs into bytes either way.
+type BlobFormat string
+
+const (
+	Base64 BlobFormat = "base64"
+	Hex    BlobFormat = "hex"
+)
+
+// The size of a blob, counted in bits as RANDOM.org does. Write sizes with the units below, e.g.
+// 16*caprice.Byte or 128*caprice.Bit.
+type BlobSize int
+
+const (
+	Bit  BlobSize = 1
+	Byte BlobSize = 8 * Bit
+)
+
+// The size in whole bytes, rounded down.
+func (s BlobSize) Bytes() int {
+	return int(s / Byte)
+}
+
+// Decodes a blob returned by generateBlobs in `format`.
+func decodeBlob(blob string, format BlobFormat) ([]byte, error) {
+	var decoded []byte
+	var err error
+	if format == Hex {
+		decoded, err = hex.DecodeString(blob)
+	} else {
+		decoded, err = base64.StdEncoding.DecodeString(blob)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: blob is not valid %s: %w", ErrMalformedResponse, format, err)
+	}
+	return decoded, nil
+}
+
+// A helper function that decodes the data of a generateBlobs result.
+func decodeBlobs(data []interface{}, format BlobFormat) ([][]byte, error) {
+	blobs := make([][]byte, len(data))
+	for i, blob := range data {
+		encoded, ok := blob.(string)
+		if !ok {
+			return [][]byte{}, fmt.Errorf("%w: %v is not a blob", ErrMalformedResponse, blob)
+		}
+		decoded, err := decodeBlob(encoded, format)
+		if err != nil {
+			return [][]byte{}, err
+		}
+		blobs[i] = decoded
+	}
+	return blobs, nil
+}
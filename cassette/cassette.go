@@ -0,0 +1,230 @@
+// Package cassette records RANDOM.org traffic to a fixture file and replays it later, so tests can use
+// real responses without network access.
+//
+// A Recorder is an http.RoundTripper: hand it to a client with caprice.WithHTTPClient. In Record mode it
+// forwards every request and keeps each request/response pair; Save writes them to the cassette file
+// with API keys scrubbed. In Replay mode it loads that file and answers each request with the recorded
+// response whose method and params match, without touching the network.
+//
+//	recorder, err := cassette.New("testdata/draw.json", cassette.Replay)
+//	rng := caprice.TrueRNG(apiKey, caprice.WithHTTPClient(recorder.Client()))
+//
+// Responses are stored byte-for-byte, so signed results replay with signatures that still verify.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// What a Recorder does with the requests it sees.
+type Mode int
+
+const (
+	// Forward requests to the network and keep what happens.
+	Record Mode = iota
+	// Serve requests from a cassette file, failing those it has no recording for.
+	Replay
+)
+
+// Stands in for API keys in recorded requests and responses.
+const Scrubbed = "[SCRUBBED]"
+
+// One recorded HTTP round trip.
+type Interaction struct {
+	// The JSON-RPC request (or batch) with its API key scrubbed and without `jsonrpc` and `id`, in
+	// canonical form. Replayed requests match on this.
+	Request json.RawMessage `json:"request"`
+	// The HTTP status of the response.
+	Status int `json:"status"`
+	// The response body exactly as received, except for any API key echoed back. It is kept as a
+	// string so that encoding the cassette cannot alter it.
+	Response string `json:"response"`
+}
+
+// The contents of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Records or replays JSON-RPC traffic. Safe for concurrent use.
+type Recorder struct {
+	// The transport requests are forwarded to in Record mode. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	path string
+	mode Mode
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// A helper function that returns a Recorder for the cassette file at `path`. In Replay mode the file
+// must exist; in Record mode it is only written by Save.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+	if mode == Replay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: %s is not a cassette: %w", path, err)
+		}
+		// the file is indented, the requests we compare against are not
+		for i, interaction := range r.cassette.Interactions {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, interaction.Request); err != nil {
+				return nil, fmt.Errorf("cassette: %s: %w", path, err)
+			}
+			r.cassette.Interactions[i].Request = compact.Bytes()
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// An http.Client that sends everything through this Recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Everything recorded or loaded so far.
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Writes everything recorded to the cassette file, replacing its contents.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return nil
+}
+
+// Records or replays a single JSON-RPC round trip.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	key, apiKeys, err := canonical(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == Replay {
+		return r.replay(req, key)
+	}
+
+	forwarded := req.Clone(req.Context())
+	forwarded.Body = io.NopCloser(bytes.NewReader(body))
+	forwarded.ContentLength = int64(len(body))
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(forwarded)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	text, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := string(text)
+	for _, apiKey := range apiKeys {
+		recorded = strings.ReplaceAll(recorded, apiKey, Scrubbed)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: key, Status: resp.StatusCode, Response: recorded})
+	r.used = append(r.used, true)
+	r.mu.Unlock()
+
+	return response(req, resp.StatusCode, text), nil
+}
+
+// Serves the first unused recording matching `key`. Identical requests are answered in the order
+// they were recorded.
+func (r *Recorder) replay(req *http.Request, key json.RawMessage) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] && bytes.Equal(interaction.Request, key) {
+			r.used[i] = true
+			return response(req, interaction.Status, []byte(interaction.Response)), nil
+		}
+	}
+	return nil, fmt.Errorf("cassette: no unused recording in %s for %s", r.path, key)
+}
+
+func response(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// Reduces a JSON-RPC request or batch to what identifies it: methods and params, API keys scrubbed,
+// keys sorted. Also returns the API keys it scrubbed.
+func canonical(body []byte) (json.RawMessage, []string, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, nil, fmt.Errorf("cassette: request is not JSON: %w", err)
+	}
+
+	var apiKeys []string
+	strip := func(call interface{}) interface{} {
+		shell, ok := call.(map[string]interface{})
+		if !ok {
+			return call
+		}
+		delete(shell, "jsonrpc")
+		delete(shell, "id")
+		if params, ok := shell["params"].(map[string]interface{}); ok {
+			if apiKey, ok := params["apiKey"].(string); ok && apiKey != "" {
+				apiKeys = append(apiKeys, apiKey)
+				params["apiKey"] = Scrubbed
+			}
+		}
+		return shell
+	}
+
+	if batch, ok := decoded.([]interface{}); ok {
+		for i, call := range batch {
+			batch[i] = strip(call)
+		}
+	} else {
+		decoded = strip(decoded)
+	}
+
+	key, err := json.Marshal(decoded)
+	return key, apiKeys, err
+}
@@ -0,0 +1,72 @@
+package cassette_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AkshatM/caprice"
+	"github.com/AkshatM/caprice/cassette"
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestCassette(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	server := randomtest.NewServer()
+
+	recorder, err := cassette.New(path, cassette.Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording := caprice.TrueRNG("secret-key", caprice.WithEndpoint(server.URL), caprice.WithHTTPClient(recorder.Client()))
+	integers, err := recording.GenerateIntegers(5, 1, 100, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := recording.GenerateSignedStrings(3, 6, "abc<&", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	t.Run("API keys are scrubbed", func(t *testing.T) {
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), "secret-key") {
+			t.Errorf("API key leaked into %s", data)
+		}
+	})
+
+	t.Run("Responses replay without a server", func(t *testing.T) {
+		player, err := cassette.New(path, cassette.Replay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replaying := caprice.TrueRNG("another-key", caprice.WithEndpoint(server.URL), caprice.WithHTTPClient(player.Client()))
+
+		replayed, err := replaying.GenerateIntegers(5, 1, 100, true)
+		if err != nil || !reflect.DeepEqual(replayed, integers) {
+			t.Errorf("replayed %v, %v; recorded %v", replayed, err, integers)
+		}
+		replayedSigned, err := replaying.GenerateSignedStrings(3, 6, "abc<&", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := caprice.NewVerifier(server.PublicKey()).Verify(replayedSigned); !ok || err != nil {
+			t.Errorf("replayed signature does not verify: %v", err)
+		}
+		if replayedSigned.Signature != signed.Signature {
+			t.Error("replayed a different signature")
+		}
+
+		if _, err := replaying.GenerateIntegers(5, 1, 100, true); !errors.Is(err, caprice.ErrTransport) {
+			t.Errorf("expected unmatched requests to fail, got %v", err)
+		}
+	})
+}
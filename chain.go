@@ -0,0 +1,121 @@
+package caprice
+
+import (
+	"errors"
+	"fmt"
+)
+
+// One Generator in a Chain, with the name its values are tagged with.
+type Link struct {
+	Name      string
+	Generator Generator
+}
+
+// Values drawn through a Chain, tagged with the Name of the Link that produced them.
+type Tagged[T any] struct {
+	Data   T
+	Source string
+}
+
+// Tries a list of Generators in order, moving on to the next whenever one is over quota or budget, has
+// its API key rejected or stopped, or is unreachable or unavailable. Errors caused by the parameters themselves are returned straight away,
+// since every Generator would reject them alike. Every result is tagged with its source, so values
+// that were not truly random can be told apart:
+//
+//	chain := caprice.NewChain(
+//		caprice.Link{Name: "random.org", Generator: caprice.TrueRNG(apiKey)},
+//		caprice.Link{Name: "crypto/rand", Generator: caprice.CryptoRNG()},
+//	)
+//	draw, err := chain.GenerateIntegers(6, 1, 49, false)
+//	if draw.Source != "random.org" { ... }
+type Chain struct {
+	links []Link
+}
+
+// A helper function that returns a Chain trying `links` in the order given.
+func NewChain(links ...Link) *Chain {
+	return &Chain{links: links}
+}
+
+// Whether `err` is a failure of the Generator rather than of the request, so the next Link may succeed.
+func fallsThrough(err error) bool {
+	return errors.Is(err, ErrQuotaExhausted) || errors.Is(err, ErrOverBudget) || errors.Is(err, ErrInvalidAPIKey) ||
+		errors.Is(err, ErrKeyStopped) || errors.Is(err, ErrTransport) || errors.Is(err, ErrServiceUnavailable)
+}
+
+// Runs `call` against each Link until one succeeds or fails for a reason the next cannot fix.
+func draw[T any](c *Chain, call func(Generator) (T, error)) (Tagged[T], error) {
+	var errs []error
+	for _, link := range c.links {
+		data, err := call(link.Generator)
+		if err == nil {
+			return Tagged[T]{Data: data, Source: link.Name}, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", link.Name, err))
+		if !fallsThrough(err) {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return Tagged[T]{}, errors.New("caprice: empty chain")
+	}
+	return Tagged[T]{}, errors.Join(errs...)
+}
+
+// Generate `n` random integers between `min` and `max`.
+// If `replacement` is true, pick random numbers with replacement.
+func (c *Chain) GenerateIntegers(n, min, max int, replacement bool) (Tagged[[]int], error) {
+	return draw(c, func(g Generator) ([]int, error) {
+		return g.GenerateIntegers(n, min, max, replacement)
+	})
+}
+
+// Generate `n` random decimal fractions with `decimalPlaces` decimal places.
+// If `replacement` is true, pick random numbers with replacement.
+func (c *Chain) GenerateDecimalFractions(n, decimalPlaces int, replacement bool) (Tagged[[]float64], error) {
+	return draw(c, func(g Generator) ([]float64, error) {
+		return g.GenerateDecimalFractions(n, decimalPlaces, replacement)
+	})
+}
+
+// Generate `n` Gaussians from a distribution with mean `mean` and stdev `standardDeviation`, returned with
+// `significantDigits` sig. digits.
+func (c *Chain) GenerateGaussians(n int, mean, standardDeviation float64, significantDigits int) (Tagged[[]float64], error) {
+	return draw(c, func(g Generator) ([]float64, error) {
+		return g.GenerateGaussians(n, mean, standardDeviation, significantDigits)
+	})
+}
+
+// Generate `n` random strings of `length` characters drawn from `characters`.
+// If `replacement` is true, the same string may be returned more than once.
+func (c *Chain) GenerateStrings(n, length int, characters string, replacement bool) (Tagged[[]string], error) {
+	return draw(c, func(g Generator) ([]string, error) {
+		return g.GenerateStrings(n, length, characters, replacement)
+	})
+}
+
+// Generate `n` random version 4 UUIDs.
+func (c *Chain) GenerateUUIDs(n int) (Tagged[[]UUID], error) {
+	return draw(c, func(g Generator) ([]UUID, error) {
+		return g.GenerateUUIDs(n)
+	})
+}
+
+// Generate `n` random blobs of `size`, sent over the wire in `format` (Base64 or Hex) if drawn remotely.
+func (c *Chain) GenerateBlobs(n int, size BlobSize, format BlobFormat) (Tagged[[][]byte], error) {
+	return draw(c, func(g Generator) ([][]byte, error) {
+		return g.GenerateBlobs(n, size, format)
+	})
+}
+
+// The usage of the first Link that can report one and still has both bits and requests left. A Link
+// reporting an empty quota is skipped, just as its draws would be.
+func (c *Chain) GetUsage() (Tagged[Status], error) {
+	return draw(c, func(g Generator) (Status, error) {
+		status, err := g.GetUsage()
+		if err == nil && (status.BitsLeft <= 0 || status.RequestsLeft <= 0) {
+			return status, fmt.Errorf("%w: %d bits and %d requests left", ErrQuotaExhausted, status.BitsLeft, status.RequestsLeft)
+		}
+		return status, err
+	})
+}
@@ -0,0 +1,83 @@
+package caprice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestChain(t *testing.T) {
+
+	t.Run("Uses the first link while it works", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		chain := NewChain(
+			Link{Name: "random.org", Generator: TrueRNG("key", WithEndpoint(server.URL))},
+			Link{Name: "crypto/rand", Generator: CryptoRNG()},
+		)
+
+		draw, err := chain.GenerateIntegers(5, 1, 10, true)
+		if err != nil || draw.Source != "random.org" || len(draw.Data) != 5 {
+			t.Errorf("got %+v, %v", draw, err)
+		}
+	})
+
+	t.Run("Falls back on quota and transport errors", func(t *testing.T) {
+		exhausted := randomtest.NewServer(randomtest.WithQuota(0, 100))
+		defer exhausted.Close()
+		second := randomtest.NewServer()
+		defer second.Close()
+		chain := NewChain(
+			Link{Name: "unreachable", Generator: TrueRNG("key", WithEndpoint("http://127.0.0.1:1"))},
+			Link{Name: "first key", Generator: TrueRNG("key", WithEndpoint(exhausted.URL))},
+			Link{Name: "second key", Generator: TrueRNG("other", WithEndpoint(second.URL))},
+		)
+
+		draw, err := chain.GenerateStrings(2, 4, "ab", true)
+		if err != nil || draw.Source != "second key" {
+			t.Errorf("got %+v, %v", draw, err)
+		}
+		usage, err := chain.GetUsage()
+		if err != nil || usage.Source != "second key" {
+			t.Errorf("got %+v, %v", usage, err)
+		}
+	})
+
+	t.Run("Falls back when the API key is rejected", func(t *testing.T) {
+		server := randomtest.NewServer(randomtest.WithAPIKeys("good"))
+		defer server.Close()
+		chain := NewChain(
+			Link{Name: "bad key", Generator: TrueRNG("bad", WithEndpoint(server.URL))},
+			Link{Name: "good key", Generator: TrueRNG("good", WithEndpoint(server.URL))},
+		)
+
+		draw, err := chain.GenerateIntegers(5, 1, 10, true)
+		if err != nil || draw.Source != "good key" {
+			t.Errorf("got %+v, %v", draw, err)
+		}
+	})
+
+	t.Run("Returns parameter errors without falling back", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		chain := NewChain(
+			Link{Name: "random.org", Generator: TrueRNG("key", WithEndpoint(server.URL))},
+			Link{Name: "crypto/rand", Generator: CryptoRNG()},
+		)
+
+		_, err := chain.GenerateIntegers(11, 1, 10, false)
+		if !errors.Is(err, ErrParameterOutOfRange) && !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("Reports every failure when all links fail", func(t *testing.T) {
+		_, err := NewChain(
+			Link{Name: "down", Generator: TrueRNG("key", WithEndpoint("http://127.0.0.1:1"))},
+		).GenerateUUIDs(1)
+		if !errors.Is(err, ErrTransport) {
+			t.Errorf("got %v", err)
+		}
+	})
+}
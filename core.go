@@ -2,29 +2,92 @@ package caprice
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // The actual URL endpoint to hit
 const endpoint string = "https://api.random.org/json-rpc/1/invoke"
 
+// The endpoint of release 4 of the API, used by clients created WithRelease(4)
+const release4Endpoint string = "https://api.random.org/json-rpc/4/invoke"
+
 // Caprice's core object. Responsible for safekeeping the API key,
 // as well as managing advisory delays in concurrent implementations.
+// Every request made through it is routed via `client` to `endpoint`.
 type trueRNG struct {
-	apiKey string
+	apiKey       string
+	client       *http.Client
+	endpoint     string
+	userAgent    string
+	release      int
+	pregenerated *PregeneratedRandomization
+	retry        RetryPolicy
+	logger       *slog.Logger
+	scheduler    *scheduler
+	quota        *quotaTracker
+	archive      *Archive
+	verifier     Verifier
 }
 
-// A helper function that will return a new trueRNG object
-func TrueRNG(apiKey string) trueRNG {
-	return trueRNG{apiKey: apiKey}
+// A helper function that will return a new trueRNG object, configured by any `options` supplied.
+// Without options, requests go to RANDOM.org through http.DefaultClient.
+func TrueRNG(apiKey string, options ...Option) trueRNG {
+	config := config{client: http.DefaultClient, release: 1}
+	for _, option := range options {
+		option(&config)
+	}
+
+	if config.endpoint == "" {
+		config.endpoint = endpoint
+		if config.release >= 4 {
+			config.endpoint = release4Endpoint
+		}
+	}
+
+	logger := config.logger
+	if logger == nil {
+		logger = slog.New(discardHandler{})
+	}
+
+	client := config.client
+	if config.timeout > 0 {
+		// copy the client so that we never mutate one the caller shares elsewhere
+		withTimeout := *client
+		withTimeout.Timeout = config.timeout
+		client = &withTimeout
+	}
+
+	return trueRNG{
+		apiKey:       apiKey,
+		client:       client,
+		endpoint:     config.endpoint,
+		userAgent:    config.userAgent,
+		release:      config.release,
+		pregenerated: config.pregenerated,
+		retry:        config.retry.withDefaults(),
+		logger:       logger,
+		scheduler:    newScheduler(config.failFast, logger),
+		quota:        newQuotaTracker(config.budget),
+		archive:      config.archive,
+		verifier:     config.verifier,
+	}
 }
 
+// The client used by the package-level Request and SignedRequest functions, so that they too
+// honour advisory delays across calls.
+var defaultRNG = TrueRNG("")
+
 // The outer JSON wrapper we send in our request body. It contains
 // `params`, which is a JSON object containing all the method parameters.
-// Typically, a struct implementing RequestShell will be populated for you.
+// Typically, a struct implementing RequestShell will be populated for you. A Batch sends several
+// of them at once as a JSON array, telling their responses apart by `Id`.
 type RequestShell struct {
 	Version string      `json:"jsonrpc"`
 	Params  interface{} `json:"params"`
@@ -71,7 +134,7 @@ type Status struct {
 // basic diagnostic information. One of four types implementing Response
 // interface; returned directly as part of the GetUsage method.
 type VerifiedSignature struct {
-	Authenticity bool `json:"authenticiity"`
+	Authenticity bool `json:"authenticity"`
 }
 
 // A nested inner JSON wrapper around Random within ResponseShell.Result. It includes some
@@ -84,30 +147,71 @@ type SignedResult struct {
 	BitsLeft      int             `json:"bitsLeft"`
 	RequestsLeft  int             `json:"requestsLeft"`
 	AdvisoryDelay int             `json:"advisoryDelay"`
+	Cost          float64         `json:"cost,omitempty"`
 }
 
 // A deeply nested inner JSON object contained inside ResponseShell.Random, which includes
 // everything we asked for. For basic methods, `Data` and `CompletionTime` are returned.
-// For signed methods, the values `HashedApiKey` and `SerialNumber` are also returned.
+// For signed methods, the values `HashedApiKey` and `SerialNumber` are also returned, and
+// release 4 adds the `Method` that produced the data, the `License` it was issued under and,
+// for draws made with a ticket, the ticket's place in its chain, and any `UserData` the request carried.
 type Random struct {
-	Data           []interface{} `json:"data"`
-	CompletionTime string        `json:"completionTime"`
-	SerialNumber   int           `json:"serialNumber"`
-	HashedApiKey   string        `json:"hashedApiKey"`
+	Data           []interface{}   `json:"data"`
+	CompletionTime string          `json:"completionTime"`
+	SerialNumber   int             `json:"serialNumber"`
+	HashedApiKey   string          `json:"hashedApiKey"`
+	Method         string          `json:"method,omitempty"`
+	License        json.RawMessage `json:"license,omitempty"`
+	TicketData     *TicketData     `json:"ticketData,omitempty"`
+	UserData       json.RawMessage `json:"userData,omitempty"`
 }
 
 type SignedIntegerData struct {
 	Raw          json.RawMessage
 	HashedApiKey string
 	SerialNumber int
+	TicketData   *TicketData
+	UserData     json.RawMessage
 	Data         []int
 	Signature    string
 }
 
+type SignedIntegerSequenceData struct {
+	Raw          json.RawMessage
+	HashedApiKey string
+	SerialNumber int
+	TicketData   *TicketData
+	UserData     json.RawMessage
+	Data         [][]int
+	Signature    string
+}
+
+type SignedBlobData struct {
+	Raw          json.RawMessage
+	HashedApiKey string
+	SerialNumber int
+	TicketData   *TicketData
+	UserData     json.RawMessage
+	Data         [][]byte
+	Signature    string
+}
+
+type SignedUUIDData struct {
+	Raw          json.RawMessage
+	HashedApiKey string
+	SerialNumber int
+	TicketData   *TicketData
+	UserData     json.RawMessage
+	Data         []UUID
+	Signature    string
+}
+
 type SignedFloatData struct {
 	Raw          json.RawMessage
 	HashedApiKey string
 	SerialNumber int
+	TicketData   *TicketData
+	UserData     json.RawMessage
 	Data         []float64
 	Signature    string
 }
@@ -117,6 +221,8 @@ type SignedStringData struct {
 	HashedApiKey string
 	Data         []string
 	SerialNumber int
+	TicketData   *TicketData
+	UserData     json.RawMessage
 	Signature    string
 }
 
@@ -143,8 +249,9 @@ func (vs VerifiedSignature) Content() interface{} {
 	return vs
 }
 
-// Core error object. Includes error message, the status code returned by the API,
-// and optional data returned by the upstream API.
+// Core error object, returned whenever the API itself reports an error. Includes error message,
+// the JSON-RPC error code returned by the API, and optional data returned by the upstream API.
+// Match it against the Err* values in errors.go with errors.Is.
 type Error struct {
 	Code    int           `json:"code"`
 	Message string        `json:"message"`
@@ -157,6 +264,22 @@ type IntegersReq struct {
 	Min         int    `json:"min"`
 	Max         int    `json:"max"`
 	Replacement bool   `json:"replacement"`
+	// One of 2, 8, 10 or 16. Omitted, like 0, it means 10; in other bases values come back as strings.
+	Base int `json:"base,omitempty"`
+	Release4Params
+}
+
+// Sequence `i` holds Length[i] integers between Min[i] and Max[i], drawn in Base[i]. Every slice has
+// one entry per sequence; Base may be left nil for base 10 throughout.
+type IntegerSequencesReq struct {
+	ApiKey      string `json:"apiKey"`
+	N           int    `json:"n"`
+	Length      []int  `json:"length"`
+	Min         []int  `json:"min"`
+	Max         []int  `json:"max"`
+	Replacement []bool `json:"replacement"`
+	Base        []int  `json:"base,omitempty"`
+	Release4Params
 }
 
 type DecimalFractionsReq struct {
@@ -164,6 +287,7 @@ type DecimalFractionsReq struct {
 	N             int    `json:"n"`
 	DecimalPlaces int    `json:"decimalPlaces"`
 	Replacement   bool   `json:"replacement"`
+	Release4Params
 }
 
 type GaussiansReq struct {
@@ -172,6 +296,7 @@ type GaussiansReq struct {
 	Mean              float64 `json:"mean"`
 	StandardDeviation float64 `json:"standardDeviation"`
 	SignificantDigits int     `json:"significantDigits"`
+	Release4Params
 }
 
 type StringsReq struct {
@@ -180,114 +305,240 @@ type StringsReq struct {
 	Length      int    `json:"length"`
 	Characters  string `json:"characters"`
 	Replacement bool   `json:"replacement"`
+	Release4Params
 }
 
 type UUIDsReq struct {
 	ApiKey string `json:"apiKey"`
 	N      int    `json:"n"`
+	Release4Params
 }
 
 type BlobsReq struct {
-	ApiKey string `json:"apiKey"`
-	N      int    `json:"n"`
-	Size   int    `json:"size"`
-	Format string `json:"format"`
+	ApiKey string     `json:"apiKey"`
+	N      int        `json:"n"`
+	Size   BlobSize   `json:"size"`
+	Format BlobFormat `json:"format"`
+	Release4Params
 }
 
 type StatusReq struct {
 	ApiKey string `json:"apiKey"`
 }
 
+type GetResultReq struct {
+	ApiKey       string `json:"apiKey"`
+	SerialNumber int    `json:"serialNumber"`
+}
+
 type VerifySignatureReq struct {
 	Raw       map[string]interface{} `json:"random"`
 	Signature string                 `json:"signature"`
 }
 
-func (e Error) Error() string {
-	return fmt.Sprintf("Code: %d, Error: %s", e.Code, e.Message)
+// A helper function that makes HTTP calls to RANDOM.org with our request parameters and method name.
+// Calls wait their turn on the client's scheduler, and the advisory delay of every successful response
+// holds back the next call. Transient failures are retried according to the client's retry policy.
+// Waiting, like the HTTP call itself, is tied to `ctx`. Params that can be validated locally are, so
+// that bad parameters fail without a round trip.
+func (rng trueRNG) _request(ctx context.Context, method string, params interface{}) (ResponseShell, error) {
+
+	if err := rng.checkRelease(method, params); err != nil {
+		return ResponseShell{}, err
+	}
+	if err := validate(params); err != nil {
+		return ResponseShell{}, err
+	}
+	if err := rng.checkBudget(ctx, params); err != nil {
+		return ResponseShell{}, err
+	}
+
+	var response ResponseShell
+	err := rng.retrying(ctx, unrepeatable(method), func() error {
+		var err error
+		response, err = rng.attempt(ctx, method, params)
+		return err
+	})
+	if err != nil {
+		rng.quota.release(params)
+		rng.logger.WarnContext(ctx, logError, "method", method, "error", rng.redact(err))
+	}
+	return response, err
 }
 
-func clientError(message string) (ResponseShell, Error) {
-	return ResponseShell{}, Error{
-		Code:    409,
-		Message: message,
+// Makes a single scheduled call: waits for our turn, posts, and records the advisory delay.
+func (rng trueRNG) attempt(ctx context.Context, method string, params interface{}) (ResponseShell, error) {
+
+	if err := rng.scheduler.acquire(ctx); err != nil {
+		return ResponseShell{}, err
+	}
+
+	rng.logger.DebugContext(ctx, logRequest, "method", method, "params", scrubbed{params})
+	start := time.Now()
+
+	response, err := rng.post(ctx, method, params)
+	delay := advisoryDelay(response.Result)
+	rng.scheduler.release(delay)
+
+	if err == nil {
+		rng.quota.update(method, params, response.Result)
+		rng.logger.DebugContext(ctx, logResponse, "method", method, "duration", time.Since(start),
+			"advisoryDelay", delay, "result", scrubbed{response.Result})
 	}
+	return response, err
 }
 
-// A helper function that makes HTTP calls to RANDOM.org with our request parameters and method name.
-func _request(method string, params interface{}) (ResponseShell, Error) {
+// Performs a single JSON-RPC call over HTTP, without any scheduling.
+func (rng trueRNG) post(ctx context.Context, method string, params interface{}) (ResponseShell, error) {
 
-	// create the JSON body for our request - ID is set to any number, doesn't matter which as API doesn't support batch notifs.
+	// create the JSON body for our request - ID is set to any number, doesn't matter which for a single call.
 	body, err := json.Marshal(RequestShell{Version: "2.0", Params: params, Method: method, Id: 1})
+	if err != nil {
+		return ResponseShell{}, fmt.Errorf("caprice: cannot encode %s request: %w", method, err)
+	}
 
-	fmt.Println(string(body))
+	text, err := rng.roundTrip(ctx, body)
 	if err != nil {
-		return clientError(err.Error())
+		return ResponseShell{}, err
 	}
 
+	// unmarshall response data
+	response := ResponseShell{}
+	if err := json.Unmarshal(text, &response); err != nil {
+		return ResponseShell{}, fmt.Errorf("%w: %w", ErrMalformedResponse, err)
+	}
+	return response, response.err()
+}
+
+// POSTs an encoded JSON-RPC body and returns the body of a successful response.
+func (rng trueRNG) roundTrip(ctx context.Context, body []byte) ([]byte, error) {
+
 	// fire off the POST request
-	resp, err := http.Post(endpoint, "application/json-rpc", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rng.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("caprice: cannot build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json-rpc")
+	if rng.userAgent != "" {
+		req.Header.Set("User-Agent", rng.userAgent)
+	}
+
+	resp, err := rng.client.Do(req)
 	if err != nil {
-		return clientError(err.Error())
+		return nil, fmt.Errorf("%w: %w", ErrTransport, err)
 	}
 	defer resp.Body.Close()
 
 	// convert resp.Body into a buffer we can unmarshall from
 	text, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return clientError(err.Error())
+		return nil, fmt.Errorf("%w: %w", ErrTransport, err)
 	}
 
-	// handle non-successful behaviour
+	// handle non-successful behaviour, preferring any JSON-RPC error the server explained itself with
 	if resp.StatusCode != 200 {
-		errorMessage := Error{}
-		json.Unmarshal(text, &errorMessage)
-		return ResponseShell{}, errorMessage
+		response := ResponseShell{}
+		if json.Unmarshal(text, &response) == nil && response.Error.Message != "" {
+			return nil, response.Error
+		}
+		return nil, StatusError{StatusCode: resp.StatusCode}
 	}
 
-	// unmarshall response data
-	response := ResponseShell{}
-	json.Unmarshal(text, &response)
+	return text, nil
+}
 
-	if response.Error.Message != "" {
-		return ResponseShell{}, response.Error
+// The error a decoded response carries, if any. A response with neither result nor error is malformed.
+func (response ResponseShell) err() error {
+	if response.Error.Code != 0 || response.Error.Message != "" {
+		return response.Error
+	}
+	if len(response.Result) == 0 {
+		return fmt.Errorf("%w: neither result nor error present", ErrMalformedResponse)
 	}
+	return nil
+}
 
-	return response, Error{}
+// Sends `method` with `params` to RANDOM.org using a default client, and returns the result
+// as either a Status or a Result. `params` must carry its own API key.
+func Request(method string, params interface{}) (Response, error) {
+	return defaultRNG.RequestContext(context.Background(), method, params)
 }
 
-func Request(method string, params interface{}) (Response, Error) {
+// Sends `method` with `params` to RANDOM.org using a default client, and returns the result
+// as either a VerifiedSignature or a SignedResult. `params` must carry its own API key.
+func SignedRequest(method string, params interface{}) (Response, error) {
+	return defaultRNG.SignedRequestContext(context.Background(), method, params)
+}
+
+// Same as Request, but routed through this client's HTTP client and endpoint.
+func (rng trueRNG) Request(method string, params interface{}) (Response, error) {
+	return rng.RequestContext(context.Background(), method, params)
+}
 
-	response, err := _request(method, params)
-	if err.Message != "" {
+// Same as rng.Request, but bound to `ctx`.
+func (rng trueRNG) RequestContext(ctx context.Context, method string, params interface{}) (Response, error) {
+
+	response, err := rng._request(ctx, method, params)
+	if err != nil {
 		return nil, err
 	}
 
 	if method == "getUsage" {
 		status := Status{}
-		json.Unmarshal(response.Result, &status)
-		return status, Error{}
+		if err := decodeResult(response.Result, &status); err != nil {
+			return nil, err
+		}
+		return status, nil
 	}
 
 	result := Result{}
-	json.Unmarshal(response.Result, &result)
-	return result, Error{}
+	if err := decodeResult(response.Result, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-func SignedRequest(method string, params interface{}) (Response, Error) {
+// Same as SignedRequest, but routed through this client's HTTP client and endpoint.
+func (rng trueRNG) SignedRequest(method string, params interface{}) (Response, error) {
+	return rng.SignedRequestContext(context.Background(), method, params)
+}
 
-	response, err := _request(method, params)
-	if err.Message != "" {
+// Same as rng.SignedRequest, but bound to `ctx`.
+func (rng trueRNG) SignedRequestContext(ctx context.Context, method string, params interface{}) (Response, error) {
+
+	response, err := rng._request(ctx, method, params)
+	if err != nil {
 		return nil, err
 	}
 
 	if method == "verifySignature" {
 		verifiedSignature := VerifiedSignature{}
-		json.Unmarshal(response.Result, &verifiedSignature)
-		return verifiedSignature, Error{}
+		if err := decodeResult(response.Result, &verifiedSignature); err != nil {
+			return nil, err
+		}
+		return verifiedSignature, nil
 	}
 
 	result := SignedResult{}
-	json.Unmarshal(response.Result, &result)
-	return result, Error{}
+	if err := decodeResult(response.Result, &result); err != nil {
+		return nil, err
+	}
+	rng.archiveResult(ctx, method, result)
+	return result, nil
+}
+
+// Reports whether `method` draws a new signed result.
+func signedDraw(method string) bool {
+	return strings.HasPrefix(method, "generateSigned")
+}
+
+// Appends a freshly drawn signed `result` to the client's archive, if it has one. Results fetched again
+// with getResult are already archived. Failures are logged, never returned.
+func (rng trueRNG) archiveResult(ctx context.Context, method string, result SignedResult) {
+	if rng.archive == nil || !signedDraw(method) {
+		return
+	}
+	if err := rng.archive.AppendRaw(result.Raw, result.Signature); err != nil {
+		rng.logger.WarnContext(ctx, logArchive, "method", method, "error", rng.redact(err))
+	}
 }
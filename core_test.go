@@ -0,0 +1,78 @@
+package caprice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContext(t *testing.T) {
+
+	t.Run("A deadline aborts a slow request", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		rng := TrueRNG("key", WithEndpoint(server.URL))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if _, err := rng.GenerateSignedBlobsContext(ctx, 1, 8, "hex"); err == nil {
+			t.Error("expected the request to be cancelled")
+		}
+		if time.Since(start) > time.Second {
+			t.Error("request outlived its deadline")
+		}
+	})
+}
+
+func TestAdvisoryDelay(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","result":{"random":{"data":[1]},"advisoryDelay":100},"id":1}`))
+	}))
+	defer server.Close()
+
+	t.Run("Later calls wait for the advisory delay", func(t *testing.T) {
+		rng := TrueRNG("key", WithEndpoint(server.URL))
+		if _, err := rng.GenerateIntegers(1, 1, 1, false); err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		if _, err := rng.GenerateIntegers(1, 1, 1, false); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("second call went out after %v", elapsed)
+		}
+	})
+
+	t.Run("Fail fast returns an error instead of waiting", func(t *testing.T) {
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithFailFast())
+		if _, err := rng.GenerateIntegers(1, 1, 1, false); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rng.GenerateIntegers(1, 1, 1, false); !errors.Is(err, ErrWouldBlock) {
+			t.Errorf("expected an advisory delay error, got %v", err)
+		}
+	})
+
+	t.Run("Waiting respects the context", func(t *testing.T) {
+		rng := TrueRNG("key", WithEndpoint(server.URL))
+		if _, err := rng.GenerateIntegers(1, 1, 1, false); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := rng.GenerateIntegersContext(ctx, 1, 1, 1, false); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the wait to be cut short, got %v", err)
+		}
+	})
+}
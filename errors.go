@@ -0,0 +1,95 @@
+package caprice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Every error returned by this package can be matched against one of these with errors.Is.
+// Errors reported by RANDOM.org itself are of type Error, and match according to their code.
+var (
+	// The API key has run out of bits or requests (codes 402 and 403).
+	ErrQuotaExhausted = errors.New("caprice: quota exhausted")
+	// The API key does not exist (code 400).
+	ErrInvalidAPIKey = errors.New("caprice: invalid API key")
+	// The API key exists but has been stopped by its owner (code 401).
+	ErrKeyStopped = errors.New("caprice: API key stopped")
+	// A parameter was missing, malformed or of the wrong type (codes -32602, 200, 201 and 203-205).
+	ErrInvalidParameter = errors.New("caprice: invalid parameter")
+	// A parameter was well-formed but outside the range the API accepts, or the request asked for more
+	// than it allows (codes 202 and 300-305).
+	ErrParameterOutOfRange = errors.New("caprice: parameter out of range")
+	// RANDOM.org is down for maintenance or failed internally (codes 100, -32603 and 32000).
+	ErrServiceUnavailable = errors.New("caprice: service unavailable")
+	// The request never got a complete HTTP response, or got one with a non-200 status.
+	ErrTransport = errors.New("caprice: transport failure")
+	// The server answered, but not with a JSON-RPC response we could decode.
+	ErrMalformedResponse = errors.New("caprice: malformed response")
+	// The call needs a newer release of the API than the client was created with; see WithRelease.
+	ErrUnsupportedRelease = errors.New("caprice: not supported by this API release")
+	// The client was created WithFailFast and the call would have had to wait its turn.
+	ErrWouldBlock = errors.New("caprice: call would block")
+	// A signature could not be checked because no public key is available to check it with.
+	ErrNoPublicKey = errors.New("caprice: no public key to verify with")
+	// A signed result does not carry a valid signature: it was altered, or signed with another key.
+	ErrBadSignature = errors.New("caprice: bad signature")
+)
+
+func (e Error) Error() string {
+	return fmt.Sprintf("Code: %d, Error: %s", e.Code, e.Message)
+}
+
+// The Err* value each of RANDOM.org's documented error codes stands for. Codes missing here, such as
+// those about tickets, match none of them.
+var errorCodes = map[int]error{
+	-32602: ErrInvalidParameter,
+	-32603: ErrServiceUnavailable,
+	100:    ErrServiceUnavailable,
+	200:    ErrInvalidParameter,
+	201:    ErrInvalidParameter,
+	202:    ErrParameterOutOfRange,
+	203:    ErrInvalidParameter,
+	204:    ErrInvalidParameter,
+	205:    ErrInvalidParameter,
+	300:    ErrParameterOutOfRange,
+	301:    ErrParameterOutOfRange,
+	302:    ErrParameterOutOfRange,
+	303:    ErrParameterOutOfRange,
+	304:    ErrParameterOutOfRange,
+	305:    ErrParameterOutOfRange,
+	400:    ErrInvalidAPIKey,
+	401:    ErrKeyStopped,
+	402:    ErrQuotaExhausted,
+	403:    ErrQuotaExhausted,
+	32000:  ErrServiceUnavailable,
+}
+
+// Maps RANDOM.org's error codes onto the Err* values above.
+func (e Error) Is(target error) bool {
+	sentinel, ok := errorCodes[e.Code]
+	return ok && sentinel == target
+}
+
+// Returned when the server answers with a non-200 HTTP status and no JSON-RPC error to explain it.
+// It matches ErrTransport, and ErrServiceUnavailable for 5xx statuses.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("caprice: unexpected HTTP status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e StatusError) Is(target error) bool {
+	return target == ErrTransport || (target == ErrServiceUnavailable && e.StatusCode >= 500)
+}
+
+// Unmarshals a JSON-RPC result into `v`, reporting failure as a malformed response.
+func decodeResult(result json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(result, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedResponse, err)
+	}
+	return nil
+}
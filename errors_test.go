@@ -0,0 +1,99 @@
+package caprice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestErrors(t *testing.T) {
+
+	server := randomtest.NewServer(randomtest.WithAPIKeys("key"))
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL))
+
+	t.Run("API error codes map onto typed errors", func(t *testing.T) {
+		cases := map[int]error{
+			400:    ErrInvalidAPIKey,
+			401:    ErrKeyStopped,
+			402:    ErrQuotaExhausted,
+			403:    ErrQuotaExhausted,
+			200:    ErrInvalidParameter,
+			201:    ErrInvalidParameter,
+			203:    ErrInvalidParameter,
+			204:    ErrInvalidParameter,
+			205:    ErrInvalidParameter,
+			-32602: ErrInvalidParameter,
+			202:    ErrParameterOutOfRange,
+			300:    ErrParameterOutOfRange,
+			301:    ErrParameterOutOfRange,
+			305:    ErrParameterOutOfRange,
+			100:    ErrServiceUnavailable,
+			-32603: ErrServiceUnavailable,
+			32000:  ErrServiceUnavailable,
+		}
+		for code, expected := range cases {
+			server.InjectError(code, "injected")
+			_, err := rng.GenerateIntegers(1, 1, 10, true)
+			var apiError Error
+			if !errors.Is(err, expected) || !errors.As(err, &apiError) || apiError.Code != code {
+				t.Errorf("code %d: got %v, expected %v", code, err, expected)
+			}
+		}
+	})
+
+	t.Run("Codes outside the table match nothing", func(t *testing.T) {
+		sentinels := []error{ErrQuotaExhausted, ErrInvalidAPIKey, ErrKeyStopped, ErrInvalidParameter,
+			ErrParameterOutOfRange, ErrServiceUnavailable}
+		for _, code := range []int{206, 299, 306, 399, 404, 420, -32601} {
+			for _, sentinel := range sentinels {
+				if errors.Is(Error{Code: code}, sentinel) {
+					t.Errorf("code %d matches %v", code, sentinel)
+				}
+			}
+		}
+		if errors.Is(Error{Code: 202}, ErrInvalidParameter) {
+			t.Error("code 202 is out of range, not invalid")
+		}
+	})
+
+	t.Run("Unknown keys are rejected", func(t *testing.T) {
+		_, err := TrueRNG("other", WithEndpoint(server.URL)).GetUsage()
+		if !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("Bare HTTP failures are transport errors", func(t *testing.T) {
+		server.InjectHTTPStatus(http.StatusBadGateway)
+		_, err := rng.GenerateUUIDs(1)
+		var statusError StatusError
+		if !errors.Is(err, ErrTransport) || !errors.Is(err, ErrServiceUnavailable) || !errors.As(err, &statusError) || statusError.StatusCode != 502 {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("Cancellation is a transport error that keeps its cause", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := rng.GenerateUUIDsContext(ctx, 1)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("Garbage responses are malformed", func(t *testing.T) {
+		garbage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html>"))
+		}))
+		defer garbage.Close()
+		_, err := TrueRNG("key", WithEndpoint(garbage.URL)).GenerateUUIDs(1)
+		if !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("got %v", err)
+		}
+	})
+}
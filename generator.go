@@ -0,0 +1,185 @@
+package caprice
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// The basic API, as implemented by the RANDOM.org client returned by TrueRNG and by the local backends
+// returned by PseudoRNG and CryptoRNG. Code written against Generator can switch between them, or use a
+// fake in tests. Every implementation takes the same parameters with the same meaning and limits.
+type Generator interface {
+	GenerateIntegers(n, min, max int, replacement bool) ([]int, error)
+	GenerateDecimalFractions(n, decimalPlaces int, replacement bool) ([]float64, error)
+	GenerateGaussians(n int, mean, standardDeviation float64, significantDigits int) ([]float64, error)
+	GenerateStrings(n, length int, characters string, replacement bool) ([]string, error)
+	GenerateUUIDs(n int) ([]UUID, error)
+	GenerateBlobs(n int, size BlobSize, format BlobFormat) ([][]byte, error)
+	GetUsage() (Status, error)
+}
+
+var (
+	_ Generator = trueRNG{}
+	_ Generator = (*localRNG)(nil)
+)
+
+// A Generator that draws from a local math/rand generator instead of RANDOM.org. It validates its
+// parameters with the same request structs the client sends, so code that works against one works
+// against the other. Safe for concurrent use.
+type localRNG struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// A helper function that returns a Generator drawing pseudo-random numbers from math/rand, seeded
+// with `seed`. The same seed always yields the same values; use it for reproducible tests and simulations.
+func PseudoRNG(seed int64) *localRNG {
+	return &localRNG{rand: rand.New(rand.NewSource(seed))}
+}
+
+// A helper function that returns a Generator drawing from crypto/rand, the operating system's CSPRNG.
+func CryptoRNG() *localRNG {
+	return &localRNG{rand: rand.New(cryptoSource{})}
+}
+
+// A math/rand Source64 reading from crypto/rand.
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var buffer [8]byte
+	if _, err := cryptorand.Read(buffer[:]); err != nil {
+		panic(fmt.Sprintf("caprice: crypto/rand failed: %v", err))
+	}
+	return binary.LittleEndian.Uint64(buffer[:])
+}
+
+func (s cryptoSource) Int63() int64 { return int64(s.Uint64() >> 1) }
+func (cryptoSource) Seed(int64)     {}
+
+// Draws `n` values with `draw`, retrying duplicates when `replacement` is false. Callers make sure
+// that enough distinct values exist.
+func distinct[T comparable](n int, replacement bool, draw func() T) []T {
+	values := make([]T, 0, n)
+	seen := map[T]bool{}
+	for len(values) < n {
+		value := draw()
+		if !replacement && seen[value] {
+			continue
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+	return values
+}
+
+// Generate `n` random integers between `min` and `max`.
+// If `replacement` is true, pick random numbers with replacement.
+func (l *localRNG) GenerateIntegers(n, min, max int, replacement bool) ([]int, error) {
+	if err := (IntegersReq{N: n, Min: min, Max: max, Replacement: replacement}).Validate(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return distinct(n, replacement, func() int { return min + l.rand.Intn(max-min+1) }), nil
+}
+
+// Generate `n` random decimal fractions in [0, 1) with `decimalPlaces` decimal places.
+// If `replacement` is true, pick random numbers with replacement.
+func (l *localRNG) GenerateDecimalFractions(n, decimalPlaces int, replacement bool) ([]float64, error) {
+	if err := (DecimalFractionsReq{N: n, DecimalPlaces: decimalPlaces, Replacement: replacement}).Validate(); err != nil {
+		return nil, err
+	}
+	scale := math.Pow10(decimalPlaces)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return distinct(n, replacement, func() float64 { return math.Floor(l.rand.Float64()*scale) / scale }), nil
+}
+
+// Generate `n` Gaussians from a distribution with mean `mean` and stdev `standardDeviation`, returned with
+// `significantDigits` sig. digits.
+func (l *localRNG) GenerateGaussians(n int, mean, standardDeviation float64, significantDigits int) ([]float64, error) {
+	request := GaussiansReq{N: n, Mean: mean, StandardDeviation: standardDeviation, SignificantDigits: significantDigits}
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	gaussians := make([]float64, n)
+	for i := range gaussians {
+		gaussians[i] = roundSignificant(mean+standardDeviation*l.rand.NormFloat64(), significantDigits)
+	}
+	return gaussians, nil
+}
+
+func roundSignificant(value float64, digits int) float64 {
+	if value == 0 {
+		return 0
+	}
+	scale := math.Pow10(digits - 1 - int(math.Floor(math.Log10(math.Abs(value)))))
+	return math.Round(value*scale) / scale
+}
+
+// Generate `n` random strings of `length` characters drawn from `characters`.
+// If `replacement` is true, the same string may be returned more than once.
+func (l *localRNG) GenerateStrings(n, length int, characters string, replacement bool) ([]string, error) {
+	if err := (StringsReq{N: n, Length: length, Characters: characters, Replacement: replacement}).Validate(); err != nil {
+		return nil, err
+	}
+	runes := []rune(characters)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return distinct(n, replacement, func() string {
+		value := make([]rune, length)
+		for i := range value {
+			value[i] = runes[l.rand.Intn(len(runes))]
+		}
+		return string(value)
+	}), nil
+}
+
+// Generate `n` random version 4 UUIDs.
+func (l *localRNG) GenerateUUIDs(n int) ([]UUID, error) {
+	if err := (UUIDsReq{N: n}).Validate(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	uuids := make([]UUID, n)
+	for i := range uuids {
+		l.rand.Read(uuids[i][:])
+		uuids[i][6] = uuids[i][6]&0x0f | 0x40 // version 4
+		uuids[i][8] = uuids[i][8]&0x3f | 0x80 // RFC 4122 variant
+	}
+	return uuids, nil
+}
+
+// Generate `n` random blobs of `size`. `format` is only checked, since nothing is encoded locally.
+func (l *localRNG) GenerateBlobs(n int, size BlobSize, format BlobFormat) ([][]byte, error) {
+	if err := (BlobsReq{N: n, Size: size, Format: format}).Validate(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	blobs := make([][]byte, n)
+	for i := range blobs {
+		blobs[i] = make([]byte, size.Bytes())
+		l.rand.Read(blobs[i])
+	}
+	return blobs, nil
+}
+
+// Local generators have no quota: they always report themselves running with the most bits and
+// requests a Status can hold.
+func (l *localRNG) GetUsage() (Status, error) {
+	return Status{Status: "running", BitsLeft: math.MaxInt32, RequestsLeft: math.MaxInt32}, nil
+}
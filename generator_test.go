@@ -0,0 +1,107 @@
+package caprice
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestGenerator(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+
+	backends := map[string]Generator{
+		"RANDOM.org":  TrueRNG("key", WithEndpoint(server.URL)),
+		"math/rand":   PseudoRNG(42),
+		"crypto/rand": CryptoRNG(),
+	}
+
+	for name, generator := range backends {
+		t.Run(name, func(t *testing.T) {
+			integers, err := generator.GenerateIntegers(10, 1, 10, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen := map[int]bool{}
+			for _, value := range integers {
+				if value < 1 || value > 10 || seen[value] {
+					t.Errorf("bad draw without replacement: %v", integers)
+				}
+				seen[value] = true
+			}
+
+			fractions, err := generator.GenerateDecimalFractions(5, 2, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, value := range fractions {
+				if value < 0 || value >= 1 || math.Abs(value*100-math.Round(value*100)) > 1e-9 {
+					t.Errorf("%v does not have 2 decimal places", value)
+				}
+			}
+
+			if _, err := generator.GenerateGaussians(5, 0, 1, 4); err != nil {
+				t.Error(err)
+			}
+
+			strings, err := generator.GenerateStrings(5, 8, "abc", true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, value := range strings {
+				if !regexp.MustCompile(`^[abc]{8}$`).MatchString(value) {
+					t.Errorf("%q is not made of abc", value)
+				}
+			}
+
+			uuids, err := generator.GenerateUUIDs(2)
+			if err != nil || len(uuids) != 2 {
+				t.Errorf("got %v, %v", uuids, err)
+			}
+			blobs, err := generator.GenerateBlobs(2, 8*Byte, Hex)
+			if err != nil || len(blobs) != 2 || len(blobs[0]) != 8 {
+				t.Errorf("got %v, %v", blobs, err)
+			}
+
+			status, err := generator.GetUsage()
+			if err != nil || status.Status != "running" {
+				t.Errorf("got %v, %v", status, err)
+			}
+		})
+	}
+
+	t.Run("Local backends enforce the API's limits", func(t *testing.T) {
+		if _, err := PseudoRNG(1).GenerateIntegers(11, 1, 10, false); !errors.Is(err, ErrParameterOutOfRange) {
+			t.Errorf("got %v", err)
+		}
+		if _, err := CryptoRNG().GenerateDecimalFractions(1, 15, true); !errors.Is(err, ErrParameterOutOfRange) {
+			t.Errorf("got %v", err)
+		}
+		if _, err := PseudoRNG(1).GenerateBlobs(1, 8, "octal"); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("Seeded pseudo-random backends repeat themselves", func(t *testing.T) {
+		first, _ := PseudoRNG(7).GenerateIntegers(5, 0, 1000, true)
+		second, _ := PseudoRNG(7).GenerateIntegers(5, 0, 1000, true)
+		for i := range first {
+			if first[i] != second[i] {
+				t.Fatalf("%v != %v", first, second)
+			}
+		}
+	})
+
+	t.Run("UUIDs are version 4", func(t *testing.T) {
+		uuids, _ := CryptoRNG().GenerateUUIDs(10)
+		for _, uuid := range uuids {
+			if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid.String()) {
+				t.Errorf("%s is not a version 4 UUID", uuid)
+			}
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package caprice
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// Log records are emitted under these messages, so handlers can filter on them.
+const (
+	logRequest       = "caprice request"
+	logResponse      = "caprice response"
+	logAdvisoryDelay = "caprice advisory delay"
+	logRetry         = "caprice retry"
+	logError         = "caprice error"
+	logBudget        = "caprice over budget"
+	logArchive       = "caprice archive"
+)
+
+// The placeholder logged in place of API keys and hashed API keys.
+const redactedValue = "[REDACTED]"
+
+// Emit structured records about this client's traffic to `logger`: requests and responses at debug level,
+// advisory delay waits and retries at info level, and failed calls at warn level. API keys and hashed API
+// keys never appear in any record. By default, nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// A slog.Handler that drops everything; the logger of clients created without WithLogger.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
+// Wraps request params or results so that they are only encoded, and scrubbed of keys, if a record is
+// actually logged.
+type scrubbed struct {
+	value interface{}
+}
+
+func (s scrubbed) LogValue() slog.Value {
+	encoded, err := json.Marshal(s.value)
+	if err != nil {
+		return slog.StringValue(err.Error())
+	}
+	var decoded interface{}
+	json.Unmarshal(encoded, &decoded)
+	return slog.AnyValue(scrub(decoded))
+}
+
+// Replaces every `apiKey` and `hashedApiKey` in a decoded JSON value.
+func scrub(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range value {
+			if key == "apiKey" || key == "hashedApiKey" {
+				value[key] = redactedValue
+			} else {
+				value[key] = scrub(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range value {
+			value[i] = scrub(inner)
+		}
+	}
+	return value
+}
+
+// The message of `err` with this client's API key taken out; RANDOM.org echoes unknown keys back.
+func (rng trueRNG) redact(err error) string {
+	if rng.apiKey == "" {
+		return err.Error()
+	}
+	return strings.ReplaceAll(err.Error(), rng.apiKey, redactedValue)
+}
@@ -0,0 +1,40 @@
+package caprice
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestLogging(t *testing.T) {
+
+	server := randomtest.NewServer(randomtest.WithAdvisoryDelay(10 * time.Millisecond))
+	defer server.Close()
+
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	rng := TrueRNG("secret-key", WithEndpoint(server.URL), WithLogger(logger),
+		WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+
+	signed, err := rng.GenerateSignedIntegers(3, 1, 10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.InjectError(100, "The server is unavailable")
+	server.InjectError(400, "The API key secret-key does not exist")
+	rng.GenerateIntegers(3, 1, 10, true)
+
+	logs := buffer.String()
+	for _, message := range []string{logRequest, logResponse, logAdvisoryDelay, logRetry, logError} {
+		if !strings.Contains(logs, message) {
+			t.Errorf("no %q record in %s", message, logs)
+		}
+	}
+	if strings.Contains(logs, "secret-key") || strings.Contains(logs, signed.HashedApiKey) {
+		t.Errorf("keys leaked into %s", logs)
+	}
+}
@@ -0,0 +1,92 @@
+package caprice
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// The settings an Option may change before TrueRNG builds its trueRNG object.
+type config struct {
+	client    *http.Client
+	endpoint  string
+	timeout   time.Duration
+	userAgent string
+	failFast  bool
+
+	release      int
+	pregenerated *PregeneratedRandomization
+	retry        RetryPolicy
+	logger       *slog.Logger
+	budget       Budget
+	archive      *Archive
+	verifier     Verifier
+}
+
+// An Option customises how a trueRNG object talks to RANDOM.org. Pass any number of them to TrueRNG.
+type Option func(*config)
+
+// Send every request through `client` instead of http.DefaultClient. Useful for proxies,
+// custom transports or TLS settings.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		if client != nil {
+			c.client = client
+		}
+	}
+}
+
+// Send every request to `url` instead of RANDOM.org's JSON-RPC endpoint, e.g. an internal mirror
+// or a local fake server in tests.
+func WithEndpoint(url string) Option {
+	return func(c *config) {
+		c.endpoint = url
+	}
+}
+
+// Abort any request that takes longer than `timeout`, including reading the response body.
+// The HTTP client in use is copied rather than modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// Identify ourselves to the server as `userAgent` on every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *config) {
+		c.userAgent = userAgent
+	}
+}
+
+// Return an error straight away whenever a call would otherwise have to wait, either for another
+// request on the same client to finish or for RANDOM.org's advisory delay to elapse.
+func WithFailFast() Option {
+	return func(c *config) {
+		c.failFast = true
+	}
+}
+
+// Talk to `release` of the JSON-RPC API: 1 (the default) or 4. Unless WithEndpoint is also given, the
+// release decides which endpoint requests go to. Release 4 is needed for the ticket and getResult methods
+// and for the parameters in Release4Params.
+func WithRelease(release int) Option {
+	return func(c *config) {
+		c.release = release
+	}
+}
+
+// Draw all data from the pregenerated randomization `p` instead of fresh randomness, so that the same
+// calls return the same values every time. Requires release 4.
+func WithPregeneratedRandomization(p PregeneratedRandomization) Option {
+	return func(c *config) {
+		c.pregenerated = &p
+	}
+}
+
+// Retry calls that fail transiently according to `policy`. By default, calls are never retried.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = policy
+	}
+}
@@ -0,0 +1,54 @@
+package caprice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOptions(t *testing.T) {
+
+	t.Run("Requests go to the configured endpoint with the configured user agent", func(t *testing.T) {
+		var received RequestShell
+		var userAgent string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.Header.Get("User-Agent")
+			json.NewDecoder(r.Body).Decode(&received)
+			w.Write([]byte(`{"jsonrpc":"2.0","result":{"random":{"data":[1,2,3]},"advisoryDelay":0},"id":1}`))
+		}))
+		defer server.Close()
+
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithUserAgent("caprice-test"))
+		numbers, err := rng.GenerateIntegers(3, 1, 3, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(numbers) != 3 || numbers[2] != 3 {
+			t.Errorf("unexpected data %v", numbers)
+		}
+		if received.Method != "generateIntegers" {
+			t.Errorf("server saw method %q", received.Method)
+		}
+		if userAgent != "caprice-test" {
+			t.Errorf("server saw user agent %q", userAgent)
+		}
+	})
+
+	t.Run("Timeouts are applied without modifying the supplied client", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		client := &http.Client{}
+		rng := TrueRNG("key", WithHTTPClient(client), WithEndpoint(server.URL), WithTimeout(20*time.Millisecond))
+		if _, err := rng.GetUsage(); err == nil {
+			t.Error("expected a timeout error")
+		}
+		if client.Timeout != 0 {
+			t.Error("supplied client was modified")
+		}
+	})
+}
@@ -0,0 +1,239 @@
+package caprice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Returned, without anything being sent, when a call would take a client past its Budget.
+var ErrOverBudget = errors.New("caprice: over budget")
+
+// Caps what a client may spend. Calls that would break the budget are refused with ErrOverBudget, or
+// only logged at warn level if WarnOnly is set. Zero fields impose no limit.
+type Budget struct {
+	// The most bits this client may spend in total.
+	Bits int
+	// The most requests this client may send in total.
+	Requests int
+	// Bits of the API key's allowance that must be left unspent, whoever spends the rest.
+	ReserveBits int
+	// Log calls over budget instead of refusing them.
+	WarnOnly bool
+	// How old the quota model may grow before it is refreshed from getUsage. If zero, it is only updated
+	// from the results of calls the client makes.
+	RefreshInterval time.Duration
+}
+
+// Limit what this client may spend; see Budget.
+func WithBudget(budget Budget) Option {
+	return func(c *config) {
+		c.budget = budget
+	}
+}
+
+// A snapshot of a client's quota model. BitsLeft and RequestsLeft are the latest figures RANDOM.org
+// reported for the whole API key; they are -1 until the first response or refresh.
+type Quota struct {
+	BitsLeft     int
+	RequestsLeft int
+	// What this client has spent, across all its copies.
+	BitsSpent    int
+	RequestsSent int
+	// When RANDOM.org last reported BitsLeft and RequestsLeft.
+	Updated time.Time
+}
+
+// Implemented by request structs whose cost can be worked out before they are sent.
+type estimator interface {
+	EstimatedBits() int
+}
+
+// The in-memory quota model shared by every copy of a trueRNG.
+type quotaTracker struct {
+	budget Budget
+
+	mu    sync.Mutex
+	quota Quota
+	// What calls that passed checkBudget but have not been settled are expected to spend.
+	reservedBits, reservedRequests int
+}
+
+func newQuotaTracker(budget Budget) *quotaTracker {
+	return &quotaTracker{budget: budget, quota: Quota{BitsLeft: -1, RequestsLeft: -1}}
+}
+
+// The quota model as it stands.
+func (rng trueRNG) Quota() Quota {
+	rng.quota.mu.Lock()
+	defer rng.quota.mu.Unlock()
+	return rng.quota.quota
+}
+
+// The bits and requests a call with `params` is expected to spend; zero for calls that cannot be estimated.
+func estimate(params interface{}) (bits, requests int) {
+	if e, ok := params.(estimator); ok {
+		return e.EstimatedBits(), 1
+	}
+	return 0, 0
+}
+
+// Settles the reservation made for `params` and folds the quota figures in its result, of a generate
+// call or of getUsage, into the model.
+func (q *quotaTracker) update(method string, params interface{}, result json.RawMessage) {
+	var figures struct {
+		BitsUsed     int  `json:"bitsUsed"`
+		BitsLeft     *int `json:"bitsLeft"`
+		RequestsLeft *int `json:"requestsLeft"`
+	}
+	known := json.Unmarshal(result, &figures) == nil && figures.BitsLeft != nil && figures.RequestsLeft != nil
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.settle(params)
+	if !known {
+		return
+	}
+	q.quota.BitsLeft, q.quota.RequestsLeft, q.quota.Updated = *figures.BitsLeft, *figures.RequestsLeft, time.Now()
+	if method != "getUsage" {
+		q.quota.BitsSpent += figures.BitsUsed
+		q.quota.RequestsSent++
+	}
+}
+
+// Gives back the reservations made for calls that failed.
+func (q *quotaTracker) release(calls ...interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, params := range calls {
+		q.settle(params)
+	}
+}
+
+// Drops the reservation made for `params`. The caller holds the lock.
+func (q *quotaTracker) settle(params interface{}) {
+	bits, requests := estimate(params)
+	q.reservedBits -= bits
+	q.reservedRequests -= requests
+}
+
+// Whether the model is due a refresh from getUsage.
+func (q *quotaTracker) stale() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.budget.RefreshInterval > 0 && time.Since(q.quota.Updated) > q.budget.RefreshInterval
+}
+
+// Checks whether spending `bits` more over `requests` more requests would break the budget, counting
+// what other calls have reserved as spent, and reserves them if not, or if the budget only warns.
+// Checking and reserving under one lock keeps concurrent calls from each passing on a budget that only
+// fits one of them.
+func (q *quotaTracker) reserve(bits, requests int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	budget, quota := q.budget, q.quota
+	quota.BitsSpent += q.reservedBits
+	quota.RequestsSent += q.reservedRequests
+	if quota.BitsLeft >= 0 {
+		quota.BitsLeft -= q.reservedBits
+	}
+	if quota.RequestsLeft >= 0 {
+		quota.RequestsLeft -= q.reservedRequests
+	}
+
+	var err error
+	switch {
+	case budget.Bits > 0 && quota.BitsSpent+bits > budget.Bits:
+		err = fmt.Errorf("%w: %d bits would bring spending to %d of %d", ErrOverBudget, bits, quota.BitsSpent+bits, budget.Bits)
+	case budget.Requests > 0 && quota.RequestsSent+requests > budget.Requests:
+		err = fmt.Errorf("%w: %d of %d requests already sent", ErrOverBudget, quota.RequestsSent, budget.Requests)
+	case quota.BitsLeft >= 0 && quota.BitsLeft-bits < budget.ReserveBits:
+		err = fmt.Errorf("%w: %d bits would leave %d, below the %d reserved", ErrOverBudget, bits, quota.BitsLeft-bits, budget.ReserveBits)
+	case quota.RequestsLeft >= 0 && quota.RequestsLeft < requests:
+		err = fmt.Errorf("%w: %d requests left", ErrOverBudget, quota.RequestsLeft)
+	}
+	if err == nil || budget.WarnOnly {
+		q.reservedBits += bits
+		q.reservedRequests += requests
+	}
+	return err
+}
+
+// The pre-flight check run before every call: refreshes a stale model, estimates what `calls` will
+// cost and refuses, or warns about, calls that would break the budget. The estimate of calls let through
+// stays reserved until quota.update settles it, or quota.release gives it back.
+func (rng trueRNG) checkBudget(ctx context.Context, calls ...interface{}) error {
+	bits, requests := 0, 0
+	for _, params := range calls {
+		b, r := estimate(params)
+		bits, requests = bits+b, requests+r
+	}
+	if requests == 0 {
+		return nil
+	}
+
+	if rng.quota.stale() {
+		if _, err := rng.GetUsageContext(ctx); err != nil {
+			rng.logger.WarnContext(ctx, logError, "method", "getUsage", "error", rng.redact(err))
+		}
+	}
+
+	err := rng.quota.reserve(bits, requests)
+	if err != nil && rng.quota.budget.WarnOnly {
+		rng.logger.WarnContext(ctx, logBudget, "bits", bits, "error", err)
+		return nil
+	}
+	return err
+}
+
+// How many bits `n` values, each picked from `choices` equally likely ones, cost. RANDOM.org charges
+// the entropy of the draw as a whole, rounded, not a whole number of bits per value: six dice cost 16.
+func bitsFor(n int, choices float64) int {
+	if choices <= 1 {
+		return 0
+	}
+	return int(math.Round(float64(n) * math.Log2(choices)))
+}
+
+// The bits RANDOM.org will charge for the request.
+func (r IntegersReq) EstimatedBits() int {
+	return bitsFor(r.N, float64(r.Max)-float64(r.Min)+1)
+}
+
+// The bits RANDOM.org will charge for the request: the sum over its sequences.
+func (r IntegerSequencesReq) EstimatedBits() int {
+	bits := 0
+	for i := 0; i < len(r.Length) && i < len(r.Min) && i < len(r.Max); i++ {
+		bits += IntegersReq{N: r.Length[i], Min: r.Min[i], Max: r.Max[i]}.EstimatedBits()
+	}
+	return bits
+}
+
+// The bits RANDOM.org will charge for the request.
+func (r DecimalFractionsReq) EstimatedBits() int {
+	return bitsFor(r.N, math.Pow10(r.DecimalPlaces))
+}
+
+// The bits RANDOM.org will charge for the request.
+func (r GaussiansReq) EstimatedBits() int {
+	return bitsFor(r.N, math.Pow10(r.SignificantDigits))
+}
+
+// The bits RANDOM.org will charge for the request.
+func (r StringsReq) EstimatedBits() int {
+	return bitsFor(r.N*r.Length, float64(len([]rune(r.Characters))))
+}
+
+// The bits RANDOM.org will charge for the request: the 122 random bits of each version 4 UUID.
+func (r UUIDsReq) EstimatedBits() int {
+	return r.N * 122
+}
+
+// The bits RANDOM.org will charge for the request.
+func (r BlobsReq) EstimatedBits() int {
+	return r.N * int(r.Size)
+}
@@ -0,0 +1,191 @@
+package caprice
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestQuota(t *testing.T) {
+
+	t.Run("Tracks every response", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		rng := TrueRNG("key", WithEndpoint(server.URL))
+
+		if quota := rng.Quota(); quota.BitsLeft != -1 {
+			t.Errorf("expected an unknown quota before any call, got %+v", quota)
+		}
+		rng.GenerateIntegers(10, 1, 16, true)
+		rng.GenerateUUIDs(1)
+
+		quota := rng.Quota()
+		if quota.BitsSpent != 10*4+122 || quota.RequestsSent != 2 {
+			t.Errorf("got %+v", quota)
+		}
+		if quota.BitsLeft != randomtest.DefaultBits-quota.BitsSpent || quota.RequestsLeft != randomtest.DefaultRequests-2 {
+			t.Errorf("got %+v", quota)
+		}
+	})
+
+	t.Run("Estimates match what RANDOM.org charges", func(t *testing.T) {
+		// the requests and costs of the examples in RANDOM.org's API documentation
+		documented := []struct {
+			method  string
+			request estimator
+			bits    int
+		}{
+			{"generateIntegers", IntegersReq{N: 6, Min: 1, Max: 6, Replacement: true}, 16},
+			{"generateDecimalFractions", DecimalFractionsReq{N: 10, DecimalPlaces: 8, Replacement: true}, 266},
+			{"generateGaussians", GaussiansReq{N: 4, Mean: 0, StandardDeviation: 1, SignificantDigits: 8}, 106},
+			{"generateStrings", StringsReq{N: 8, Length: 10, Characters: "abcdefghijklmnopqrstuvwxyz", Replacement: true}, 376},
+			{"generateUUIDs", UUIDsReq{N: 1}, 122},
+			{"generateBlobs", BlobsReq{N: 1, Size: 1024, Format: "base64"}, 1024},
+		}
+		var options []randomtest.Option
+		for _, example := range documented {
+			options = append(options, randomtest.WithBitCost(example.method, example.bits))
+		}
+		server := randomtest.NewServer(options...)
+		defer server.Close()
+		rng := TrueRNG("key", WithEndpoint(server.URL))
+
+		for _, example := range documented {
+			if estimate := example.request.EstimatedBits(); estimate != example.bits {
+				t.Errorf("%s: estimated %d bits, RANDOM.org charges %d", example.method, estimate, example.bits)
+			}
+			response, err := rng.Request(example.method, example.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if used := response.(Result).BitsUsed; used != example.bits {
+				t.Errorf("%s: the server charged %d bits, expected %d", example.method, used, example.bits)
+			}
+		}
+		if quota := rng.Quota(); quota.BitsSpent != 16+266+106+376+122+1024 {
+			t.Errorf("got %+v", quota)
+		}
+	})
+
+	t.Run("Calls in flight count against the budget", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		transport := &blockingTransport{entered: make(chan struct{}), release: make(chan struct{})}
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithHTTPClient(&http.Client{Transport: transport}),
+			WithBudget(Budget{Bits: 100}))
+
+		done := make(chan error)
+		go func() {
+			_, err := rng.GenerateIntegers(15, 1, 16, true) // 60 bits
+			done <- err
+		}()
+		<-transport.entered
+
+		// the first call has not been charged yet, but 60 and 60 bits don't fit a budget of 100
+		if _, err := rng.GenerateIntegers(15, 1, 16, true); !errors.Is(err, ErrOverBudget) {
+			t.Errorf("got %v", err)
+		}
+		close(transport.release)
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+
+		// once charged, the reservation is settled rather than counted twice
+		if _, err := rng.GenerateIntegers(10, 1, 16, true); err != nil {
+			t.Error(err)
+		}
+		if quota := rng.Quota(); quota.BitsSpent != 100 || quota.RequestsSent != 2 {
+			t.Errorf("got %+v", quota)
+		}
+	})
+
+	t.Run("Failed calls give their reservation back", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithBudget(Budget{Bits: 100}))
+
+		server.InjectHTTPStatus(http.StatusBadRequest)
+		if _, err := rng.GenerateIntegers(15, 1, 16, true); err == nil {
+			t.Fatal("expected the injected failure")
+		}
+		if _, err := rng.GenerateIntegers(25, 1, 16, true); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Refuses calls over budget", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithBudget(Budget{Bits: 200, Requests: 5}))
+
+		if _, err := rng.GenerateIntegers(10, 1, 16, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rng.GenerateBlobs(1, 256, "hex"); !errors.Is(err, ErrOverBudget) {
+			t.Errorf("got %v", err)
+		}
+		if server.Calls("generateBlobs") != 0 {
+			t.Error("a call over budget was sent")
+		}
+
+		batch := rng.NewBatch()
+		uuids := batch.GenerateUUIDs(2)
+		if err := batch.Do(); !errors.Is(err, ErrOverBudget) || !errors.Is(uuids.Err(), ErrOverBudget) {
+			t.Errorf("got %v and %v", err, uuids.Err())
+		}
+	})
+
+	t.Run("Keeps the reserve", func(t *testing.T) {
+		server := randomtest.NewServer(randomtest.WithQuota(1000, 100))
+		defer server.Close()
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithBudget(Budget{ReserveBits: 900, RefreshInterval: time.Hour}))
+
+		if _, err := rng.GenerateBlobs(1, 128, "hex"); !errors.Is(err, ErrOverBudget) {
+			t.Errorf("got %v", err)
+		}
+		if server.Calls("getUsage") != 1 {
+			t.Errorf("expected one refresh, saw %d", server.Calls("getUsage"))
+		}
+		if _, err := rng.GenerateBlobs(1, 64, "hex"); err != nil {
+			t.Error(err)
+		}
+		if server.Calls("getUsage") != 1 {
+			t.Errorf("refreshed again within the interval")
+		}
+	})
+
+	t.Run("Only warns if asked to", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		var logs bytes.Buffer
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithBudget(Budget{Bits: 10, WarnOnly: true}),
+			WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+		if _, err := rng.GenerateUUIDs(1); err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(logs.String(), logBudget) {
+			t.Errorf("expected a warning, got %q", logs.String())
+		}
+	})
+}
+
+// Holds every request until `release` is closed, signalling `entered` when the first one arrives.
+type blockingTransport struct {
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (b *blockingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	b.once.Do(func() { close(b.entered) })
+	<-b.release
+	return http.DefaultTransport.RoundTrip(r)
+}
@@ -0,0 +1,439 @@
+package randomtest
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The parameters of every method, merged into one struct; each method reads the fields it needs.
+type params struct {
+	ApiKey            string  `json:"apiKey"`
+	N                 int     `json:"n"`
+	Min               int     `json:"min"`
+	Max               int     `json:"max"`
+	Replacement       *bool   `json:"replacement"`
+	DecimalPlaces     int     `json:"decimalPlaces"`
+	Mean              float64 `json:"mean"`
+	StandardDeviation float64 `json:"standardDeviation"`
+	SignificantDigits int     `json:"significantDigits"`
+	Length            int     `json:"length"`
+	Characters        string  `json:"characters"`
+	Size              int     `json:"size"`
+	Format            string  `json:"format"`
+	Base              int     `json:"base"`
+
+	// release 4
+	PregeneratedRandomization json.RawMessage `json:"pregeneratedRandomization"`
+	SerialNumber              int             `json:"serialNumber"`
+	TicketId                  string          `json:"ticketId"`
+	ShowResult                bool            `json:"showResult"`
+	TicketType                string          `json:"ticketType"`
+	UserData                  json.RawMessage `json:"userData"`
+
+	// verifySignature
+	Random    json.RawMessage `json:"random"`
+	Signature string          `json:"signature"`
+
+	// generateIntegerSequences, whose length, min, max, replacement and base are arrays
+	sequences *sequences
+}
+
+type sequences struct {
+	Length      []int  `json:"length"`
+	Min         []int  `json:"min"`
+	Max         []int  `json:"max"`
+	Replacement []bool `json:"replacement"`
+	Base        []int  `json:"base"`
+}
+
+// Decodes the params of any method into one struct.
+func decodeParams(method string, raw json.RawMessage) (params, error) {
+	if !strings.HasSuffix(method, "IntegerSequences") {
+		var p params
+		err := json.Unmarshal(raw, &p)
+		return p, err
+	}
+
+	// the arrays share their names with scalar fields of params, so decode them on their own
+	var q sequences
+	if err := json.Unmarshal(raw, &q); err != nil {
+		return params{}, err
+	}
+	var scalars map[string]json.RawMessage
+	json.Unmarshal(raw, &scalars)
+	for _, array := range []string{"length", "min", "max", "replacement", "base"} {
+		delete(scalars, array)
+	}
+	stripped, _ := json.Marshal(scalars)
+
+	var p params
+	if err := json.Unmarshal(stripped, &p); err != nil {
+		return params{}, err
+	}
+	p.sequences = &q
+	return p, nil
+}
+
+// Whether the draw is made with replacement; RANDOM.org defaults to true.
+func (p params) replacement() bool {
+	return p.Replacement == nil || *p.Replacement
+}
+
+// The draw each generate method makes, returning the data and the number of bits it consumed.
+var generators = map[string]func(s *Server, p params) ([]interface{}, int, *rpcError){
+	"generateIntegers":         (*Server).integers,
+	"generateIntegerSequences": (*Server).integerSequences,
+	"generateDecimalFractions": (*Server).decimalFractions,
+	"generateGaussians":        (*Server).gaussians,
+	"generateStrings":          (*Server).strings,
+	"generateUUIDs":            (*Server).uuids,
+	"generateBlobs":            (*Server).blobs,
+}
+
+func invalidParams(format string, args ...interface{}) *rpcError {
+	return &rpcError{Code: -32602, Message: "Invalid params: " + fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) dispatch(method string, raw json.RawMessage) (interface{}, *rpcError) {
+
+	p, err := decodeParams(method, raw)
+	if err != nil {
+		return nil, invalidParams("%v", err)
+	}
+
+	if method == "verifySignature" {
+		return s.verifySignature(p)
+	}
+
+	// the one method anybody may call, without an API key
+	if method == "getTicket" {
+		return s.getTicket(p)
+	}
+
+	if s.keys != nil && !s.keys[p.ApiKey] {
+		return nil, &rpcError{Code: 400, Message: "The API key you specified does not exist", Data: []interface{}{p.ApiKey}}
+	}
+
+	if method == "getUsage" {
+		return s.status(p.ApiKey), nil
+	}
+
+	if method == "getResult" {
+		result, ok := s.results[hashKey(p.ApiKey)][p.SerialNumber]
+		if !ok {
+			return nil, invalidParams("no signed result with serial number %d", p.SerialNumber)
+		}
+		return result, nil
+	}
+
+	switch method {
+	case "createTickets":
+		return s.createTickets(p)
+	case "revealTickets":
+		return s.revealTickets(p)
+	case "listTickets":
+		return s.listTickets(p)
+	}
+
+	signed := strings.HasPrefix(method, "generateSigned")
+	unsigned := strings.Replace(method, "generateSigned", "generate", 1)
+	generate, ok := generators[unsigned]
+	if !ok {
+		return nil, &rpcError{Code: -32601, Message: "Method not found", Data: []interface{}{method}}
+	}
+	if p.N < 1 || p.N > 10000 {
+		return nil, &rpcError{Code: 300, Message: fmt.Sprintf("Parameter 'n' must be between 1 and 10000, but was %d", p.N)}
+	}
+
+	if len(p.UserData) > 0 && string(p.UserData) != "null" {
+		if !signed {
+			return nil, invalidParams("userData is only accepted by signed methods")
+		}
+		if len([]rune(string(p.UserData))) > 1000 {
+			return nil, invalidParams("userData must encode to at most 1000 characters")
+		}
+	}
+
+	var drawn *ticket
+	if p.TicketId != "" {
+		if !signed {
+			return nil, invalidParams("ticketId is only accepted by signed methods")
+		}
+		var rpcErr *rpcError
+		if drawn, rpcErr = s.usableTicket(p.ApiKey, p.TicketId); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+
+	// a pregenerated randomization always yields the same data, independent of the server's seed
+	source := s.rand
+	if len(p.PregeneratedRandomization) > 0 && string(p.PregeneratedRandomization) != "null" {
+		seed := fnv.New64a()
+		seed.Write(p.PregeneratedRandomization)
+		s.rand = mathrand.New(mathrand.NewSource(int64(seed.Sum64())))
+	}
+	data, bits, rpcErr := generate(s, p)
+	s.rand = source
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if cost, ok := s.bitCosts[unsigned]; ok {
+		bits = cost
+	}
+	if rpcErr := s.spend(p.ApiKey, bits); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	used := s.spent(p.ApiKey)
+	result := map[string]interface{}{
+		"bitsUsed":      bits,
+		"bitsLeft":      s.bits - used.bitsUsed,
+		"requestsLeft":  s.requests - used.requestsUsed,
+		"advisoryDelay": int(s.advisoryDelay / time.Millisecond),
+	}
+	completionTime := s.created.Add(time.Duration(used.requestsUsed) * time.Second).Format("2006-01-02 15:04:05Z")
+
+	if !signed {
+		result["random"] = map[string]interface{}{"data": data, "completionTime": completionTime}
+		return result, nil
+	}
+
+	// signed results echo the request back inside `random`, userData included, with the key replaced by
+	// its hash and the ticket by its place in the chain. License data is not echoed.
+	var random map[string]interface{}
+	json.Unmarshal(raw, &random)
+	delete(random, "apiKey")
+	delete(random, "ticketId")
+	delete(random, "licenseData")
+	hashed := hashKey(p.ApiKey)
+	s.serials[hashed]++
+	random["method"] = method
+	random["hashedApiKey"] = hashed
+	random["data"] = data
+	random["completionTime"] = completionTime
+	random["serialNumber"] = s.serials[hashed]
+	if drawn != nil {
+		completed, _ := time.Parse("2006-01-02 15:04:05Z", completionTime)
+		random["ticketData"] = s.useTicket(drawn, s.serials[hashed], completed)
+	}
+
+	encoded, _ := json.Marshal(random)
+	result["random"] = json.RawMessage(encoded)
+	result["signature"] = sign(encoded)
+
+	if s.results[hashed] == nil {
+		s.results[hashed] = map[int]map[string]interface{}{}
+	}
+	s.results[hashed][s.serials[hashed]] = map[string]interface{}{"random": result["random"], "signature": result["signature"]}
+	if drawn != nil {
+		drawn.result = s.results[hashed][s.serials[hashed]]
+	}
+	return result, nil
+}
+
+func (s *Server) spent(apiKey string) *usage {
+	used, ok := s.usage[apiKey]
+	if !ok {
+		used = &usage{}
+		s.usage[apiKey] = used
+	}
+	return used
+}
+
+// Charges `bits` and one request to `apiKey`, refusing if its quota cannot cover either.
+func (s *Server) spend(apiKey string, bits int) *rpcError {
+	used := s.spent(apiKey)
+	if left := s.requests - used.requestsUsed; left < 1 {
+		return &rpcError{Code: 402, Message: fmt.Sprintf("The operation requires 1 requests, but the API key only has %d left", left), Data: []interface{}{1, left}}
+	}
+	if left := s.bits - used.bitsUsed; left < bits {
+		return &rpcError{Code: 403, Message: fmt.Sprintf("The operation requires %d bits, but the API key only has %d left", bits, left), Data: []interface{}{bits, left}}
+	}
+	used.bitsUsed += bits
+	used.requestsUsed++
+	return nil
+}
+
+func (s *Server) status(apiKey string) map[string]interface{} {
+	used := s.spent(apiKey)
+	return map[string]interface{}{
+		"status":        "running",
+		"creationTime":  s.created.Format("2006-01-02 15:04:05Z"),
+		"bitsLeft":      s.bits - used.bitsUsed,
+		"requestsLeft":  s.requests - used.requestsUsed,
+		"totalBits":     used.bitsUsed,
+		"totalRequests": used.requestsUsed,
+	}
+}
+
+// Checks a signature against the `random` object it covers. Clients may re-encode the object on the way
+// back, so it is normalised the same way dispatch encoded it before checking.
+func (s *Server) verifySignature(p params) (interface{}, *rpcError) {
+	var random map[string]interface{}
+	if err := json.Unmarshal(p.Random, &random); err != nil {
+		return nil, invalidParams("random: %v", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(p.Signature)
+	if err != nil {
+		return nil, invalidParams("signature: %v", err)
+	}
+	encoded, _ := json.Marshal(random)
+	digest := sha512.Sum512(encoded)
+	authentic := rsa.VerifyPKCS1v15(s.PublicKey(), crypto.SHA512, digest[:], signature) == nil
+	return map[string]interface{}{"authenticity": authentic}, nil
+}
+
+// The bits needed to pick `n` values from `choices` equally likely ones: the entropy of the whole draw,
+// rounded, as RANDOM.org charges it.
+func bitsFor(n int, choices float64) int {
+	if choices <= 1 {
+		return 0
+	}
+	return int(math.Round(float64(n) * math.Log2(choices)))
+}
+
+func (s *Server) integers(p params) ([]interface{}, int, *rpcError) {
+	if p.Min > p.Max {
+		return nil, 0, invalidParams("min %d is greater than max %d", p.Min, p.Max)
+	}
+	switch p.Base {
+	case 0, 2, 8, 10, 16:
+	default:
+		return nil, 0, invalidParams("base must be 2, 8, 10 or 16")
+	}
+	span := p.Max - p.Min + 1
+	if !p.replacement() && p.N > span {
+		return nil, 0, &rpcError{Code: 301, Message: fmt.Sprintf("You requested %d values without replacement but the domain you specified contains only %d", p.N, span)}
+	}
+
+	data := make([]interface{}, p.N)
+	seen := map[int]bool{}
+	for i := range data {
+		value := p.Min + s.rand.Intn(span)
+		for !p.replacement() && seen[value] {
+			value = p.Min + s.rand.Intn(span)
+		}
+		seen[value] = true
+		data[i] = value
+		if p.Base != 0 && p.Base != 10 {
+			data[i] = strconv.FormatInt(int64(value), p.Base)
+		}
+	}
+	return data, bitsFor(p.N, float64(span)), nil
+}
+
+func (s *Server) integerSequences(p params) ([]interface{}, int, *rpcError) {
+	q := p.sequences
+	if len(q.Length) != p.N || len(q.Min) != p.N || len(q.Max) != p.N || len(q.Replacement) != p.N ||
+		(q.Base != nil && len(q.Base) != p.N) {
+		return nil, 0, invalidParams("length, min, max, replacement and base must have n entries")
+	}
+
+	data := make([]interface{}, p.N)
+	total := 0
+	for i := range data {
+		replacement := q.Replacement[i]
+		sequence := params{N: q.Length[i], Min: q.Min[i], Max: q.Max[i], Replacement: &replacement}
+		if q.Base != nil {
+			sequence.Base = q.Base[i]
+		}
+		values, bits, rpcErr := s.integers(sequence)
+		if rpcErr != nil {
+			return nil, 0, rpcErr
+		}
+		data[i] = values
+		total += bits
+	}
+	return data, total, nil
+}
+
+func (s *Server) decimalFractions(p params) ([]interface{}, int, *rpcError) {
+	if p.DecimalPlaces < 1 || p.DecimalPlaces > 14 {
+		return nil, 0, invalidParams("decimalPlaces must be between 1 and 14")
+	}
+	scale := math.Pow10(p.DecimalPlaces)
+	data := make([]interface{}, p.N)
+	for i := range data {
+		data[i] = math.Floor(s.rand.Float64()*scale) / scale
+	}
+	return data, bitsFor(p.N, scale), nil
+}
+
+func (s *Server) gaussians(p params) ([]interface{}, int, *rpcError) {
+	if p.SignificantDigits < 2 || p.SignificantDigits > 14 {
+		return nil, 0, invalidParams("significantDigits must be between 2 and 14")
+	}
+	data := make([]interface{}, p.N)
+	for i := range data {
+		value := p.Mean + p.StandardDeviation*s.rand.NormFloat64()
+		data[i] = roundSignificant(value, p.SignificantDigits)
+	}
+	return data, bitsFor(p.N, math.Pow10(p.SignificantDigits)), nil
+}
+
+func roundSignificant(value float64, digits int) float64 {
+	if value == 0 {
+		return 0
+	}
+	scale := math.Pow10(digits - 1 - int(math.Floor(math.Log10(math.Abs(value)))))
+	return math.Round(value*scale) / scale
+}
+
+func (s *Server) strings(p params) ([]interface{}, int, *rpcError) {
+	characters := []rune(p.Characters)
+	if len(characters) == 0 || p.Length < 1 {
+		return nil, 0, invalidParams("length and characters must not be empty")
+	}
+	data := make([]interface{}, p.N)
+	for i := range data {
+		value := make([]rune, p.Length)
+		for j := range value {
+			value[j] = characters[s.rand.Intn(len(characters))]
+		}
+		data[i] = string(value)
+	}
+	return data, bitsFor(p.N*p.Length, float64(len(characters))), nil
+}
+
+func (s *Server) uuids(p params) ([]interface{}, int, *rpcError) {
+	data := make([]interface{}, p.N)
+	for i := range data {
+		uuid := make([]byte, 16)
+		s.rand.Read(uuid)
+		uuid[6] = uuid[6]&0x0f | 0x40 // version 4
+		uuid[8] = uuid[8]&0x3f | 0x80 // RFC 4122 variant
+		data[i] = fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+	}
+	return data, p.N * 122, nil
+}
+
+func (s *Server) blobs(p params) ([]interface{}, int, *rpcError) {
+	if p.Size < 1 || p.Size%8 != 0 {
+		return nil, 0, invalidParams("size must be a positive multiple of 8")
+	}
+	data := make([]interface{}, p.N)
+	for i := range data {
+		blob := make([]byte, p.Size/8)
+		s.rand.Read(blob)
+		switch p.Format {
+		case "hex":
+			data[i] = hex.EncodeToString(blob)
+		case "base64", "":
+			data[i] = base64.StdEncoding.EncodeToString(blob)
+		default:
+			return nil, 0, invalidParams("format must be base64 or hex")
+		}
+	}
+	return data, p.N * p.Size, nil
+}
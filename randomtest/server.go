@@ -0,0 +1,298 @@
+// Package randomtest provides an in-process fake of RANDOM.org's JSON-RPC API for use in tests.
+//
+// The fake implements every method caprice calls, draws its data from a seeded generator so runs are
+// repeatable, keeps track of a per-key quota, reports an advisory delay, signs the output of signed
+// methods with its own RSA key and lets tests inject failures ahead of time.
+//
+//	server := randomtest.NewServer(randomtest.WithSeed(42))
+//	defer server.Close()
+//	rng := caprice.TrueRNG("any key", caprice.WithEndpoint(server.URL))
+package randomtest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// The quota RANDOM.org grants a free API key every day.
+const (
+	DefaultBits     = 250000
+	DefaultRequests = 1000
+)
+
+// The signing key is expensive to generate, so every server in a test binary shares one.
+var (
+	keyOnce    sync.Once
+	signingKey *rsa.PrivateKey
+)
+
+func key() *rsa.PrivateKey {
+	keyOnce.Do(func() {
+		var err error
+		signingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic("randomtest: cannot generate signing key: " + err.Error())
+		}
+	})
+	return signingKey
+}
+
+// A running fake RANDOM.org server. Point a client at URL; the embedded httptest.Server must be closed
+// once the test is done.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	rand          *mathrand.Rand
+	bits          int
+	requests      int
+	advisoryDelay time.Duration
+	keys          map[string]bool
+	usage         map[string]*usage
+	serials       map[string]int
+	results       map[string]map[int]map[string]interface{}
+	tickets       map[string]*ticket
+	calls         map[string]int
+	failures      []failure
+	noBatches     bool
+	bitCosts      map[string]int
+	created       time.Time
+}
+
+// How much of its quota a single API key has spent.
+type usage struct {
+	bitsUsed     int
+	requestsUsed int
+}
+
+// A failure queued by InjectError or InjectHTTPStatus, served in place of the next response.
+type failure struct {
+	status int
+	err    *rpcError
+}
+
+// An Option configures a Server before it starts.
+type Option func(*Server)
+
+// Seed the generator all random data is drawn from. Servers with the same seed that receive the same
+// requests return the same data. The default seed is 1.
+func WithSeed(seed int64) Option {
+	return func(s *Server) {
+		s.rand = mathrand.New(mathrand.NewSource(seed))
+	}
+}
+
+// Give every API key `bits` bits and `requests` requests to spend, instead of the free daily allowance.
+func WithQuota(bits, requests int) Option {
+	return func(s *Server) {
+		s.bits = bits
+		s.requests = requests
+	}
+}
+
+// Report `delay` as the advisory delay of every generate response.
+func WithAdvisoryDelay(delay time.Duration) Option {
+	return func(s *Server) {
+		s.advisoryDelay = delay
+	}
+}
+
+// Only accept the given API keys; any other key is rejected with error 400. By default every key is accepted.
+func WithAPIKeys(keys ...string) Option {
+	return func(s *Server) {
+		s.keys = map[string]bool{}
+		for _, key := range keys {
+			s.keys[key] = true
+		}
+	}
+}
+
+// Reject JSON-RPC batches with an Invalid Request error, as servers without batch support do.
+func WithoutBatches() Option {
+	return func(s *Server) {
+		s.noBatches = true
+	}
+}
+
+// Charge `bits` for every call to `method`, or its signed counterpart, instead of the cost the fake works
+// out from the request. Lets a test pin the costs RANDOM.org documents for particular requests.
+func WithBitCost(method string, bits int) Option {
+	return func(s *Server) {
+		s.bitCosts[method] = bits
+	}
+}
+
+// Start a new fake server configured by `options`.
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		rand:     mathrand.New(mathrand.NewSource(1)),
+		bits:     DefaultBits,
+		requests: DefaultRequests,
+		usage:    map[string]*usage{},
+		serials:  map[string]int{},
+		results:  map[string]map[int]map[string]interface{}{},
+		tickets:  map[string]*ticket{},
+		calls:    map[string]int{},
+		bitCosts: map[string]int{},
+		created:  time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	key()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// The public half of the key signed results are signed with.
+func (s *Server) PublicKey() *rsa.PublicKey {
+	return &key().PublicKey
+}
+
+// Answer the next call with the JSON-RPC error `code`, `message` and `data`, whatever it asks for.
+// Injected failures queue up and are served in the order they were injected; in a batch, each call
+// takes the next failure in line.
+func (s *Server) InjectError(code int, message string, data ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{err: &rpcError{Code: code, Message: message, Data: data}})
+}
+
+// Answer the next HTTP request, batch or not, with a bare HTTP `status` and no JSON-RPC body.
+func (s *Server) InjectHTTPStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{status: status})
+}
+
+// The number of times `method` has been called, including calls that failed.
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// The JSON-RPC request shell.
+type rpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Id      json.RawMessage `json:"id"`
+}
+
+// The JSON-RPC response shell. Exactly one of Result and Error is set.
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	Id      json.RawMessage `json:"id"`
+}
+
+type rpcError struct {
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    []interface{} `json:"data,omitempty"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body = bytes.TrimSpace(body)
+	batch := len(body) > 0 && body[0] == '['
+
+	// injected HTTP failures still count the calls they swallow
+	if len(s.failures) > 0 && s.failures[0].err == nil {
+		var requests []rpcRequest
+		if batch {
+			json.Unmarshal(body, &requests)
+		} else {
+			requests = make([]rpcRequest, 1)
+			json.Unmarshal(body, &requests[0])
+		}
+		for _, request := range requests {
+			s.calls[request.Method]++
+		}
+		status := s.failures[0].status
+		s.failures = s.failures[1:]
+		w.WriteHeader(status)
+		return
+	}
+
+	if batch {
+		var requests []rpcRequest
+		if s.noBatches || json.Unmarshal(body, &requests) != nil || len(requests) == 0 {
+			writeJSON(w, rpcResponse{Version: "2.0", Error: &rpcError{Code: -32600, Message: "Invalid Request"}, Id: []byte("null")})
+			return
+		}
+		responses := make([]rpcResponse, len(requests))
+		for i, request := range requests {
+			responses[i] = s.handle(request)
+		}
+		writeJSON(w, responses)
+		return
+	}
+
+	var request rpcRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeJSON(w, rpcResponse{Version: "2.0", Error: &rpcError{Code: -32700, Message: "Parse error"}, Id: []byte("null")})
+		return
+	}
+	writeJSON(w, s.handle(request))
+}
+
+// Answers a single call, which may be part of a batch. Must be called with s.mu held.
+func (s *Server) handle(request rpcRequest) rpcResponse {
+	s.calls[request.Method]++
+
+	if len(s.failures) > 0 && s.failures[0].err != nil {
+		failure := s.failures[0]
+		s.failures = s.failures[1:]
+		return rpcResponse{Version: "2.0", Error: failure.err, Id: request.Id}
+	}
+
+	result, rpcErr := s.dispatch(request.Method, request.Params)
+	if rpcErr != nil {
+		return rpcResponse{Version: "2.0", Error: rpcErr, Id: request.Id}
+	}
+	return rpcResponse{Version: "2.0", Result: result, Id: request.Id}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Signs the exact bytes of `random` the way RANDOM.org does: SHA-512, RSA PKCS #1 v1.5, base64.
+func sign(random []byte) string {
+	digest := sha512.Sum512(random)
+	signature, err := rsa.SignPKCS1v15(nil, key(), crypto.SHA512, digest[:])
+	if err != nil {
+		panic("randomtest: cannot sign: " + err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// The hashed form of an API key that signed results carry instead of the key itself.
+func hashKey(apiKey string) string {
+	digest := sha512.Sum512([]byte(apiKey))
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
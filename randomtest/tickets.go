@@ -0,0 +1,154 @@
+package randomtest
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// A ticket created by createTickets, or automatically as the successor of a used one.
+type ticket struct {
+	id           string
+	hashedApiKey string
+	showResult   bool
+	created      time.Time
+	used         time.Time
+	serialNumber int
+	previous     string
+	next         string
+	result       map[string]interface{}
+}
+
+// Creates a ticket for the key hashed as `hashed`, following `previous` if it is not empty.
+// Callers hold s.mu.
+func (s *Server) newTicket(hashed string, showResult bool, previous string) *ticket {
+	digest := sha512.Sum512([]byte(fmt.Sprintf("ticket %d", len(s.tickets))))
+	t := &ticket{id: hex.EncodeToString(digest[:16]), hashedApiKey: hashed, showResult: showResult,
+		created: s.created.Add(time.Duration(len(s.tickets)) * time.Second), previous: previous}
+	s.tickets[t.id] = t
+	return t
+}
+
+func optionalString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func (t *ticket) encode(withResult bool) map[string]interface{} {
+	encoded := map[string]interface{}{
+		"ticketId":         t.id,
+		"hashedApiKey":     t.hashedApiKey,
+		"showResult":       t.showResult,
+		"creationTime":     t.created.Format("2006-01-02 15:04:05Z"),
+		"previousTicketId": optionalString(t.previous),
+		"nextTicketId":     optionalString(t.next),
+		"usedTime":         nil,
+		"serialNumber":     nil,
+		"expirationTime":   nil,
+	}
+	if !t.used.IsZero() {
+		encoded["usedTime"] = t.used.Format("2006-01-02 15:04:05Z")
+		encoded["serialNumber"] = t.serialNumber
+	}
+	if withResult && t.showResult && t.result != nil {
+		encoded["result"] = t.result
+	}
+	return encoded
+}
+
+func (s *Server) createTickets(p params) (interface{}, *rpcError) {
+	if p.N < 1 || p.N > 50 {
+		return nil, &rpcError{Code: 300, Message: fmt.Sprintf("Parameter 'n' must be between 1 and 50, but was %d", p.N)}
+	}
+	created := make([]interface{}, p.N)
+	for i := range created {
+		created[i] = s.newTicket(hashKey(p.ApiKey), p.ShowResult, "").encode(false)
+	}
+	return created, nil
+}
+
+func (s *Server) revealTickets(p params) (interface{}, *rpcError) {
+	t, rpcErr := s.ownTicket(p.ApiKey, p.TicketId)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	count := 0
+	for ; t != nil; t = s.tickets[t.previous] {
+		if !t.showResult {
+			t.showResult = true
+			count++
+		}
+	}
+	return map[string]interface{}{"ticketCount": count}, nil
+}
+
+func (s *Server) listTickets(p params) (interface{}, *rpcError) {
+	hashed := hashKey(p.ApiKey)
+	listed := []interface{}{}
+	for _, t := range s.tickets {
+		if t.hashedApiKey != hashed {
+			continue
+		}
+		var matches bool
+		switch p.TicketType {
+		case "singleton":
+			matches = t.previous == "" && t.next == ""
+		case "head":
+			matches = t.previous == "" && t.next != ""
+		case "tail":
+			matches = t.previous != "" && t.next == ""
+		default:
+			return nil, invalidParams("ticketType must be singleton, head or tail")
+		}
+		if matches {
+			listed = append(listed, t.encode(false))
+		}
+	}
+	return listed, nil
+}
+
+func (s *Server) getTicket(p params) (interface{}, *rpcError) {
+	t, ok := s.tickets[p.TicketId]
+	if !ok {
+		return nil, &rpcError{Code: 420, Message: fmt.Sprintf("Ticket %s does not exist", p.TicketId), Data: []interface{}{p.TicketId}}
+	}
+	return t.encode(true), nil
+}
+
+func (s *Server) ownTicket(apiKey, id string) (*ticket, *rpcError) {
+	t, ok := s.tickets[id]
+	if !ok {
+		return nil, &rpcError{Code: 420, Message: fmt.Sprintf("Ticket %s does not exist", id), Data: []interface{}{id}}
+	}
+	if t.hashedApiKey != hashKey(apiKey) {
+		return nil, &rpcError{Code: 421, Message: fmt.Sprintf("Ticket %s exists but does not belong to your API key", id), Data: []interface{}{id}}
+	}
+	return t, nil
+}
+
+// Checks that the ticket `id` can be used for a signed draw by `apiKey`.
+func (s *Server) usableTicket(apiKey, id string) (*ticket, *rpcError) {
+	t, rpcErr := s.ownTicket(apiKey, id)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if !t.used.IsZero() {
+		return nil, &rpcError{Code: 422, Message: fmt.Sprintf("Ticket %s has already been used", id), Data: []interface{}{id}}
+	}
+	return t, nil
+}
+
+// Marks `t` as used for the signed `result` with `serialNumber`, and creates its successor. Returns the
+// ticket data that goes into the signed `random` object.
+func (s *Server) useTicket(t *ticket, serialNumber int, completed time.Time) map[string]interface{} {
+	t.used, t.serialNumber = completed, serialNumber
+	t.next = s.newTicket(t.hashedApiKey, t.showResult, t.id).id
+	return map[string]interface{}{
+		"ticketId":         t.id,
+		"previousTicketId": optionalString(t.previous),
+		"nextTicketId":     t.next,
+	}
+}
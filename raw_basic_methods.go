@@ -1,13 +1,58 @@
 package caprice
 
+import "context"
+
 // Generate `n` random integers between `min` and `max`, but return the raw JSON from the API as a formatted Result
 // struct. If `replacement` is true, pick random numbers with replacement. Default is false.
-// We do not support base selection, since it is easy to format into the base of your choice from base 10
-func (rng trueRNG) GenerateIntegersRaw(n, min, max int, replacement bool) (Result, Error) {
+// To have RANDOM.org draw them in another base, see GenerateIntegersInBaseRaw.
+func (rng trueRNG) GenerateIntegersRaw(n, min, max int, replacement bool) (Result, error) {
+	return rng.GenerateIntegersRawContext(context.Background(), n, min, max, replacement)
+}
 
-	body := IntegersReq{ApiKey: rng.apiKey, N: n, Min: min, Max: max, Replacement: replacement}
-	result, err := Request("generateIntegers", body)
-	if err.Message != "" {
+// Same as GenerateIntegersRaw, but bound to `ctx`.
+func (rng trueRNG) GenerateIntegersRawContext(ctx context.Context, n, min, max int, replacement bool) (Result, error) {
+
+	body := IntegersReq{ApiKey: rng.apiKey, N: n, Min: min, Max: max, Replacement: replacement,
+		Release4Params: rng.release4Params()}
+	result, err := rng.RequestContext(ctx, "generateIntegers", body)
+	if err != nil {
+		return Result{}, err
+	}
+	return result.(Result), err
+}
+
+// Generate `n` random integers between `min` and `max` in `base`, but return raw JSON from the API as a
+// formatted Result struct. Outside base 10, the data holds strings in that base, exactly as received.
+// If `replacement` is true, pick random numbers with replacement.
+func (rng trueRNG) GenerateIntegersInBaseRaw(n, min, max int, replacement bool, base int) (Result, error) {
+	return rng.GenerateIntegersInBaseRawContext(context.Background(), n, min, max, replacement, base)
+}
+
+// Same as GenerateIntegersInBaseRaw, but bound to `ctx`.
+func (rng trueRNG) GenerateIntegersInBaseRawContext(ctx context.Context, n, min, max int, replacement bool, base int) (Result, error) {
+
+	body := IntegersReq{ApiKey: rng.apiKey, N: n, Min: min, Max: max, Replacement: replacement, Base: base,
+		Release4Params: rng.release4Params()}
+	result, err := rng.RequestContext(ctx, "generateIntegers", body)
+	if err != nil {
+		return Result{}, err
+	}
+	return result.(Result), err
+}
+
+// Generate integer sequences as GenerateIntegerSequences does, but return raw JSON from the API as a
+// formatted Result struct, whose data holds one array per sequence.
+func (rng trueRNG) GenerateIntegerSequencesRaw(lengths, mins, maxes []int, replacement []bool, bases []int) (Result, error) {
+	return rng.GenerateIntegerSequencesRawContext(context.Background(), lengths, mins, maxes, replacement, bases)
+}
+
+// Same as GenerateIntegerSequencesRaw, but bound to `ctx`.
+func (rng trueRNG) GenerateIntegerSequencesRawContext(ctx context.Context, lengths, mins, maxes []int, replacement []bool, bases []int) (Result, error) {
+
+	body := IntegerSequencesReq{ApiKey: rng.apiKey, N: len(lengths), Length: lengths, Min: mins, Max: maxes,
+		Replacement: replacement, Base: bases, Release4Params: rng.release4Params()}
+	result, err := rng.RequestContext(ctx, "generateIntegerSequences", body)
+	if err != nil {
 		return Result{}, err
 	}
 	return result.(Result), err
@@ -15,11 +60,17 @@ func (rng trueRNG) GenerateIntegersRaw(n, min, max int, replacement bool) (Resul
 
 // Generate `n` random decimal fractions with precision upto `decimalPlaces`, but return raw JSON from the API
 // as a formatted Result struct. If `replacement` is true, pick random numbers with replacement. Default is false.
-func (rng trueRNG) GenerateDecimalFractionsRaw(n, decimalPlaces int, replacement bool) (Result, Error) {
+func (rng trueRNG) GenerateDecimalFractionsRaw(n, decimalPlaces int, replacement bool) (Result, error) {
+	return rng.GenerateDecimalFractionsRawContext(context.Background(), n, decimalPlaces, replacement)
+}
+
+// Same as GenerateDecimalFractionsRaw, but bound to `ctx`.
+func (rng trueRNG) GenerateDecimalFractionsRawContext(ctx context.Context, n, decimalPlaces int, replacement bool) (Result, error) {
 
-	body := DecimalFractionsReq{ApiKey: rng.apiKey, N: n, DecimalPlaces: decimalPlaces, Replacement: replacement}
-	result, err := Request("generateDecimalFractions", body)
-	if err.Message != "" {
+	body := DecimalFractionsReq{ApiKey: rng.apiKey, N: n, DecimalPlaces: decimalPlaces, Replacement: replacement,
+		Release4Params: rng.release4Params()}
+	result, err := rng.RequestContext(ctx, "generateDecimalFractions", body)
+	if err != nil {
 		return Result{}, err
 	}
 	return result.(Result), err
@@ -27,12 +78,17 @@ func (rng trueRNG) GenerateDecimalFractionsRaw(n, decimalPlaces int, replacement
 
 // Generate `n` Gaussians from a disribution with mean `mean` and stdev `standardDeviation`, returned with
 // at most `significantDigits` sig. digits, but return the raw JSON response as a formatted Result struct
-func (rng trueRNG) GenerateGaussiansRaw(n int, mean, standardDeviation float64, significantDigits int) (Result, Error) {
+func (rng trueRNG) GenerateGaussiansRaw(n int, mean, standardDeviation float64, significantDigits int) (Result, error) {
+	return rng.GenerateGaussiansRawContext(context.Background(), n, mean, standardDeviation, significantDigits)
+}
+
+// Same as GenerateGaussiansRaw, but bound to `ctx`.
+func (rng trueRNG) GenerateGaussiansRawContext(ctx context.Context, n int, mean, standardDeviation float64, significantDigits int) (Result, error) {
 
 	body := GaussiansReq{ApiKey: rng.apiKey, N: n, Mean: mean, StandardDeviation: standardDeviation,
-		SignificantDigits: significantDigits}
-	result, err := Request("generateGaussians", body)
-	if err.Message != "" {
+		SignificantDigits: significantDigits, Release4Params: rng.release4Params()}
+	result, err := rng.RequestContext(ctx, "generateGaussians", body)
+	if err != nil {
 		return Result{}, err
 	}
 	return result.(Result), err
@@ -40,12 +96,17 @@ func (rng trueRNG) GenerateGaussiansRaw(n int, mean, standardDeviation float64,
 
 // Generate `n` random strings with precision upto `decimalPlaces`, but return the raw JSON response as a
 // formatted Result struct
-func (rng trueRNG) GenerateStringsRaw(n, length int, characters string, replacement bool) (Result, Error) {
+func (rng trueRNG) GenerateStringsRaw(n, length int, characters string, replacement bool) (Result, error) {
+	return rng.GenerateStringsRawContext(context.Background(), n, length, characters, replacement)
+}
+
+// Same as GenerateStringsRaw, but bound to `ctx`.
+func (rng trueRNG) GenerateStringsRawContext(ctx context.Context, n, length int, characters string, replacement bool) (Result, error) {
 
 	body := StringsReq{ApiKey: rng.apiKey, N: n, Length: length, Characters: characters,
-		Replacement: replacement}
-	result, err := Request("generateStrings", body)
-	if err.Message != "" {
+		Replacement: replacement, Release4Params: rng.release4Params()}
+	result, err := rng.RequestContext(ctx, "generateStrings", body)
+	if err != nil {
 		return Result{}, err
 	}
 	return result.(Result), err
@@ -53,23 +114,34 @@ func (rng trueRNG) GenerateStringsRaw(n, length int, characters string, replacem
 
 // Generate `n` random strings with precision upto `decimalPlaces`, but return the raw JSON response as a
 // formatted Result struct
-func (rng trueRNG) GenerateUUIDsRaw(n int) (Result, Error) {
+func (rng trueRNG) GenerateUUIDsRaw(n int) (Result, error) {
+	return rng.GenerateUUIDsRawContext(context.Background(), n)
+}
 
-	body := UUIDsReq{ApiKey: rng.apiKey, N: n}
-	result, err := Request("generateUUIDs", body)
-	if err.Message != "" {
+// Same as GenerateUUIDsRaw, but bound to `ctx`.
+func (rng trueRNG) GenerateUUIDsRawContext(ctx context.Context, n int) (Result, error) {
+
+	body := UUIDsReq{ApiKey: rng.apiKey, N: n, Release4Params: rng.release4Params()}
+	result, err := rng.RequestContext(ctx, "generateUUIDs", body)
+	if err != nil {
 		return Result{}, err
 	}
 	return result.(Result), err
 }
 
-// Generate `n` random blobs of length `size`, formatted in `format` (either base64 or hex), but return the
-// raw JSON response as a formatted Result struct
-func (rng trueRNG) GenerateBlobsRaw(n, size int, format string) (Result, Error) {
+// Generate `n` random blobs of `size`, formatted in `format` (Base64 or Hex), but return the
+// raw JSON response as a formatted Result struct, blobs still encoded
+func (rng trueRNG) GenerateBlobsRaw(n int, size BlobSize, format BlobFormat) (Result, error) {
+	return rng.GenerateBlobsRawContext(context.Background(), n, size, format)
+}
+
+// Same as GenerateBlobsRaw, but bound to `ctx`.
+func (rng trueRNG) GenerateBlobsRawContext(ctx context.Context, n int, size BlobSize, format BlobFormat) (Result, error) {
 
-	body := BlobsReq{ApiKey: rng.apiKey, N: n, Size: size, Format: format}
-	result, err := Request("generateBlobs", body)
-	if err.Message != "" {
+	body := BlobsReq{ApiKey: rng.apiKey, N: n, Size: size, Format: format,
+		Release4Params: rng.release4Params()}
+	result, err := rng.RequestContext(ctx, "generateBlobs", body)
+	if err != nil {
 		return Result{}, err
 	}
 	return result.(Result), err
@@ -0,0 +1,165 @@
+package caprice
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// RANDOM.org's limits for a single generateBlobs call.
+const (
+	maxBlobs    = 100
+	maxBlobBits = 1048576
+)
+
+// Tunes a Reader. Zero fields take the defaults noted.
+type ReaderOptions struct {
+	// Number of bytes the pool is filled up to. Defaults to 4096.
+	PoolSize int
+	// Once the pool holds fewer bytes than this, a refill starts in the background.
+	// Defaults to a quarter of PoolSize, and at least 1.
+	LowWatermark int
+	// Bits of the API key's quota the Reader must leave untouched. Refills shrink, and eventually stop
+	// with ErrQuotaExhausted, rather than dig into them.
+	ReserveBits int
+	// Blob encoding requested from the API, Base64 (the default) or Hex. Only affects the wire size.
+	Format BlobFormat
+}
+
+// An io.Reader of true random bytes. Bytes are drawn in bulk with generateBlobs into an in-memory pool
+// which is refilled in the background whenever it runs low, so most Read calls never touch the network.
+// Before each refill the API key's remaining bits are checked with getUsage, and refills never spend
+// more than is left above ReserveBits. A Reader is safe for concurrent use and must be closed.
+type Reader struct {
+	rng     trueRNG
+	options ReaderOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	ready    *sync.Cond
+	pool     []byte
+	fetching bool
+	err      error
+	closed   bool
+}
+
+// A helper function that returns a Reader drawing on this client, starting its first refill right away.
+func (rng trueRNG) NewReader(options ReaderOptions) *Reader {
+	if options.PoolSize <= 0 {
+		options.PoolSize = 4096
+	}
+	if options.LowWatermark <= 0 || options.LowWatermark > options.PoolSize {
+		options.LowWatermark = options.PoolSize / 4
+		if options.LowWatermark < 1 {
+			options.LowWatermark = 1
+		}
+	}
+	if options.Format == "" {
+		options.Format = Base64
+	}
+
+	r := &Reader{rng: rng, options: options}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.ready = sync.NewCond(&r.mu)
+
+	r.mu.Lock()
+	r.refill()
+	r.mu.Unlock()
+	return r
+}
+
+// Fills `p` from the pool, waiting for a refill only if the pool is empty. It returns the error of a
+// failed refill once the pool has run dry; the next call tries again.
+func (r *Reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for len(r.pool) == 0 || r.closed {
+		// checked first, so that a closed Reader fails even while bytes remain pooled
+		if r.closed {
+			return 0, fmt.Errorf("caprice: read from closed Reader")
+		}
+		if r.err != nil {
+			err := r.err
+			r.err = nil
+			return 0, err
+		}
+		r.refill()
+		r.ready.Wait()
+	}
+
+	n := copy(p, r.pool)
+	r.pool = r.pool[n:]
+	r.refill()
+	return n, nil
+}
+
+// Stops any refill in flight and discards the pool. Reads after Close fail.
+func (r *Reader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.pool = nil
+	r.cancel()
+	r.ready.Broadcast()
+	return nil
+}
+
+// Starts a background refill if the pool is below the watermark and none is running. Must be called
+// with r.mu held.
+func (r *Reader) refill() {
+	if r.fetching || r.closed || r.err != nil || len(r.pool) >= r.options.LowWatermark {
+		return
+	}
+	r.fetching = true
+	go r.fetch(r.options.PoolSize - len(r.pool))
+}
+
+func (r *Reader) fetch(want int) {
+	data, err := r.draw(want)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetching = false
+	r.pool = append(r.pool, data...)
+	if err != nil && !r.closed {
+		r.err = err
+	}
+	r.ready.Broadcast()
+}
+
+// Draws up to `want` bytes, as many as the quota above ReserveBits allows.
+func (r *Reader) draw(want int) ([]byte, error) {
+
+	status, err := r.rng.GetUsageContext(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+	if affordable := (status.BitsLeft - r.options.ReserveBits) / 8; affordable < want {
+		want = affordable
+	}
+	if want <= 0 {
+		return nil, fmt.Errorf("%w: %d bits left, %d reserved", ErrQuotaExhausted, status.BitsLeft, r.options.ReserveBits)
+	}
+
+	// one blob, no larger than the most a single request may return in total
+	size := want
+	if size > maxBlobBits/8 {
+		size = maxBlobBits / 8
+	}
+
+	blobs, err := r.rng.GenerateBlobsContext(r.ctx, 1, BlobSize(size)*Byte, r.options.Format)
+	if err != nil {
+		return nil, err
+	}
+	if len(blobs) != 1 {
+		return nil, fmt.Errorf("%w: %d blobs instead of 1", ErrMalformedResponse, len(blobs))
+	}
+	return blobs[0], nil
+}
@@ -0,0 +1,67 @@
+package caprice
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestReader(t *testing.T) {
+
+	t.Run("Reads are served from a refilled pool", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		reader := TrueRNG("key", WithEndpoint(server.URL)).NewReader(ReaderOptions{PoolSize: 1000, Format: "hex"})
+		defer reader.Close()
+
+		buffer := make([]byte, 5000)
+		if _, err := io.ReadFull(reader, buffer); err != nil {
+			t.Fatal(err)
+		}
+		if calls := server.Calls("generateBlobs"); calls < 5 {
+			t.Errorf("expected several refills, saw %d", calls)
+		}
+		zeroes := 0
+		for _, b := range buffer {
+			if b == 0 {
+				zeroes++
+			}
+		}
+		if zeroes > 100 {
+			t.Errorf("%d of 5000 bytes are zero", zeroes)
+		}
+	})
+
+	t.Run("Closed readers fail even with bytes pooled", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		reader := TrueRNG("key", WithEndpoint(server.URL)).NewReader(ReaderOptions{PoolSize: 100})
+		if _, err := reader.Read(make([]byte, 1)); err != nil {
+			t.Fatal(err)
+		}
+		reader.Close()
+		if n, err := reader.Read(make([]byte, 1)); n != 0 || err == nil {
+			t.Errorf("read %d bytes after Close, %v", n, err)
+		}
+		if reader.options.LowWatermark != 25 {
+			t.Errorf("expected a watermark of a quarter of the pool, got %d", reader.options.LowWatermark)
+		}
+	})
+
+	t.Run("Refills stop at the reserved quota", func(t *testing.T) {
+		server := randomtest.NewServer(randomtest.WithQuota(8*300, 100))
+		defer server.Close()
+		reader := TrueRNG("key", WithEndpoint(server.URL)).NewReader(ReaderOptions{PoolSize: 64, ReserveBits: 8 * 100})
+		defer reader.Close()
+
+		read, err := io.ReadFull(reader, make([]byte, 1000))
+		if !errors.Is(err, ErrQuotaExhausted) {
+			t.Errorf("got %v", err)
+		}
+		if read != 200 {
+			t.Errorf("read %d bytes, expected exactly the 200 above the reserve", read)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package caprice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Methods that only exist in release 4 of the API.
+var release4Methods = map[string]bool{
+	"getResult":     true,
+	"createTickets": true,
+	"revealTickets": true,
+	"listTickets":   true,
+	"getTicket":     true,
+}
+
+// Selects the pregenerated randomization a release 4 draw is made from: either the one published for
+// `Date` (formatted YYYY-MM-DD) or one derived from an arbitrary `Id`. Set exactly one of them.
+type PregeneratedRandomization struct {
+	Date string `json:"date,omitempty"`
+	Id   string `json:"id,omitempty"`
+}
+
+// Parameters only release 4 of the API understands, embedded in every generate request struct.
+// Zero fields are left out of the request. `PregeneratedRandomization` applies to all generate methods;
+// `LicenseData`, `UserData` and `TicketId` only to signed ones.
+type Release4Params struct {
+	PregeneratedRandomization *PregeneratedRandomization `json:"pregeneratedRandomization,omitempty"`
+	LicenseData               interface{}                `json:"licenseData,omitempty"`
+	UserData                  interface{}                `json:"userData,omitempty"`
+	TicketId                  string                     `json:"ticketId,omitempty"`
+}
+
+func (p Release4Params) release4() Release4Params {
+	return p
+}
+
+func (p Release4Params) isZero() bool {
+	return p.PregeneratedRandomization == nil && p.LicenseData == nil && p.UserData == nil && p.TicketId == ""
+}
+
+// The release 4 parameters this client adds to every generate request it builds.
+func (rng trueRNG) release4Params() Release4Params {
+	return Release4Params{PregeneratedRandomization: rng.pregenerated}
+}
+
+// Refuses, before anything is sent, calls that the release this client talks to cannot serve.
+func (rng trueRNG) checkRelease(method string, params interface{}) error {
+	if rng.release >= 4 {
+		return nil
+	}
+	if release4Methods[method] {
+		return fmt.Errorf("%w: %s needs release 4", ErrUnsupportedRelease, method)
+	}
+	if p, ok := params.(interface{ release4() Release4Params }); ok && !p.release4().isZero() {
+		return fmt.Errorf("%w: release 4 parameters given to %s", ErrUnsupportedRelease, method)
+	}
+	return nil
+}
+
+// Fetch the signed result with `serialNumber` that this API key generated earlier, as a formatted
+// SignedResult struct. Requires release 4.
+func (rng trueRNG) GetResultRaw(serialNumber int) (SignedResult, error) {
+	return rng.GetResultRawContext(context.Background(), serialNumber)
+}
+
+// Same as GetResultRaw, but bound to `ctx`.
+func (rng trueRNG) GetResultRawContext(ctx context.Context, serialNumber int) (SignedResult, error) {
+
+	body := GetResultReq{ApiKey: rng.apiKey, SerialNumber: serialNumber}
+	result, err := rng.SignedRequestContext(ctx, "getResult", body)
+	if err != nil {
+		return SignedResult{}, err
+	}
+	return result.(SignedResult), nil
+}
+
+// Fetch the signed result with `serialNumber` that this API key generated earlier, decoded into the
+// Signed*Data the generating call returned: a SignedIntegerData for generateSignedIntegers, and so on.
+// The signature is checked before anything is returned, with the Verifier passed to WithVerifier or
+// else DefaultVerifier. A result that does not verify fails with ErrBadSignature; if there is no key to
+// check it with, the call fails with ErrNoPublicKey instead. Requires release 4.
+func (rng trueRNG) GetResult(serialNumber int) (SignedData, error) {
+	return rng.GetResultContext(context.Background(), serialNumber)
+}
+
+// Same as GetResult, but bound to `ctx`.
+func (rng trueRNG) GetResultContext(ctx context.Context, serialNumber int) (SignedData, error) {
+
+	result, err := rng.GetResultRawContext(ctx, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	verifier := rng.verifier
+	if verifier.key == nil {
+		if verifier, err = DefaultVerifier(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := verifier.VerifyRaw(result.Raw, result.Signature); err != nil {
+		return nil, fmt.Errorf("result %d: %w", serialNumber, err)
+	}
+	return decodeSigned(result.Raw, result.Signature)
+}
+
+// Decodes a signed `random` object into the Signed*Data of the method that generated it, reading the
+// method and any base or format from the object itself.
+func decodeSigned(raw json.RawMessage, signature string) (SignedData, error) {
+
+	randomData := Random{}
+	var params struct {
+		Base   json.RawMessage `json:"base"`
+		Format BlobFormat      `json:"format"`
+	}
+	if err := json.Unmarshal(raw, &randomData); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedResponse, err)
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedResponse, err)
+	}
+
+	switch randomData.Method {
+	case "generateSignedIntegers":
+		base := 10
+		if params.Base != nil {
+			if err := json.Unmarshal(params.Base, &base); err != nil {
+				return nil, fmt.Errorf("%w: base %s", ErrMalformedResponse, params.Base)
+			}
+		}
+		integers, err := decodeIntegers(randomData.Data, base)
+		if err != nil {
+			return nil, err
+		}
+		return SignedIntegerData{Data: integers, Raw: raw, HashedApiKey: randomData.HashedApiKey,
+			SerialNumber: randomData.SerialNumber, TicketData: randomData.TicketData,
+			UserData: randomData.UserData, Signature: signature}, nil
+
+	case "generateSignedIntegerSequences":
+		// the base is either one for all sequences or one per sequence
+		var bases []int
+		if params.Base != nil && json.Unmarshal(params.Base, &bases) != nil {
+			var base int
+			if err := json.Unmarshal(params.Base, &base); err != nil {
+				return nil, fmt.Errorf("%w: base %s", ErrMalformedResponse, params.Base)
+			}
+			bases = make([]int, len(randomData.Data))
+			for i := range bases {
+				bases[i] = base
+			}
+		}
+		sequences, err := decodeIntegerSequences(randomData.Data, bases)
+		if err != nil {
+			return nil, err
+		}
+		return SignedIntegerSequenceData{Data: sequences, Raw: raw, HashedApiKey: randomData.HashedApiKey,
+			SerialNumber: randomData.SerialNumber, TicketData: randomData.TicketData,
+			UserData: randomData.UserData, Signature: signature}, nil
+
+	case "generateSignedDecimalFractions", "generateSignedGaussians":
+		floats := make([]float64, len(randomData.Data))
+		for i, num := range randomData.Data {
+			value, ok := num.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%w: %v is not a number", ErrMalformedResponse, num)
+			}
+			floats[i] = value
+		}
+		return SignedFloatData{Data: floats, Raw: raw, HashedApiKey: randomData.HashedApiKey,
+			SerialNumber: randomData.SerialNumber, TicketData: randomData.TicketData,
+			UserData: randomData.UserData, Signature: signature}, nil
+
+	case "generateSignedStrings":
+		strings := make([]string, len(randomData.Data))
+		for i, value := range randomData.Data {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: %v is not a string", ErrMalformedResponse, value)
+			}
+			strings[i] = s
+		}
+		return SignedStringData{Data: strings, Raw: raw, HashedApiKey: randomData.HashedApiKey,
+			SerialNumber: randomData.SerialNumber, TicketData: randomData.TicketData,
+			UserData: randomData.UserData, Signature: signature}, nil
+
+	case "generateSignedUUIDs":
+		uuids, err := decodeUUIDs(randomData.Data)
+		if err != nil {
+			return nil, err
+		}
+		return SignedUUIDData{Data: uuids, Raw: raw, HashedApiKey: randomData.HashedApiKey,
+			SerialNumber: randomData.SerialNumber, TicketData: randomData.TicketData,
+			UserData: randomData.UserData, Signature: signature}, nil
+
+	case "generateSignedBlobs":
+		blobs, err := decodeBlobs(randomData.Data, params.Format)
+		if err != nil {
+			return nil, err
+		}
+		return SignedBlobData{Data: blobs, Raw: raw, HashedApiKey: randomData.HashedApiKey,
+			SerialNumber: randomData.SerialNumber, TicketData: randomData.TicketData,
+			UserData: randomData.UserData, Signature: signature}, nil
+	}
+	return nil, fmt.Errorf("%w: cannot decode a result of method %q", ErrMalformedResponse, randomData.Method)
+}
+
+// Call any JSON-RPC `method` with `params`, decoding its result into `result`, which should be a pointer.
+// This is the way to reach methods this package has no dedicated support for. Advisory delays, errors
+// and release checks are handled as for every other call.
+func (rng trueRNG) Invoke(method string, params, result interface{}) error {
+	return rng.InvokeContext(context.Background(), method, params, result)
+}
+
+// Same as Invoke, but bound to `ctx`.
+func (rng trueRNG) InvokeContext(ctx context.Context, method string, params, result interface{}) error {
+
+	response, err := rng._request(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return decodeResult(response.Result, result)
+}
@@ -0,0 +1,171 @@
+package caprice
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestRelease4(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL), WithRelease(4))
+
+	t.Run("The release picks the endpoint", func(t *testing.T) {
+		if TrueRNG("key").endpoint != endpoint || TrueRNG("key", WithRelease(4)).endpoint != release4Endpoint {
+			t.Error("wrong default endpoints")
+		}
+	})
+
+	t.Run("Release 1 clients refuse release 4 calls", func(t *testing.T) {
+		old := TrueRNG("key", WithEndpoint(server.URL))
+		if _, err := old.GetResultRaw(1); !errors.Is(err, ErrUnsupportedRelease) {
+			t.Errorf("getResult: got %v", err)
+		}
+		body := IntegersReq{ApiKey: "key", N: 1, Min: 1, Max: 2, Release4Params: Release4Params{TicketId: "abc"}}
+		if _, err := old.SignedRequest("generateSignedIntegers", body); !errors.Is(err, ErrUnsupportedRelease) {
+			t.Errorf("ticketId: got %v", err)
+		}
+		if server.Calls("getResult") != 0 || server.Calls("generateSignedIntegers") != 0 {
+			t.Error("refused calls reached the server")
+		}
+	})
+
+	t.Run("Signed results can be fetched again", func(t *testing.T) {
+		drawn, err := rng.GenerateSignedIntegers(3, 1, 10, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fetched, err := rng.GetResultRaw(drawn.SerialNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(fetched.Raw) != string(drawn.Raw) || fetched.Signature != drawn.Signature {
+			t.Errorf("fetched %+v, drew %+v", fetched, drawn)
+		}
+	})
+
+	t.Run("Fetched results are typed and verified", func(t *testing.T) {
+		verified := TrueRNG("key", WithEndpoint(server.URL), WithRelease(4), WithVerifier(NewVerifier(server.PublicKey())))
+
+		integers, _ := verified.GenerateSignedIntegersInBase(3, 1, 10, true, 16)
+		sequences, _ := verified.GenerateSignedIntegerSequences([]int{2, 3}, []int{1, 1}, []int{9, 9}, []bool{true, true}, []int{2, 8})
+		gaussians, _ := verified.GenerateSignedGaussians(2, 0, 1, 4)
+		texts, _ := verified.GenerateSignedStrings(2, 4, "ab%⌘<&", true)
+		uuids, _ := verified.GenerateSignedUUIDs(2)
+		blobs, _ := verified.GenerateSignedBlobs(2, 2*Byte, Hex)
+		for _, drawn := range []SignedData{integers, sequences, gaussians, texts, uuids, blobs} {
+			serial := reflect.ValueOf(drawn).FieldByName("SerialNumber").Interface().(int)
+			fetched, err := verified.GetResult(serial)
+			if err != nil || !reflect.DeepEqual(fetched, drawn) {
+				t.Errorf("fetched %+v, %v, drew %+v", fetched, err, drawn)
+			}
+		}
+
+		other, _ := rsa.GenerateKey(rand.Reader, 1024)
+		wrong := TrueRNG("key", WithEndpoint(server.URL), WithRelease(4), WithVerifier(NewVerifier(&other.PublicKey)))
+		if _, err := wrong.GetResult(integers.SerialNumber); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("with the wrong key: got %v", err)
+		}
+	})
+
+	t.Run("Without WithVerifier, the built-in certificate is trusted", func(t *testing.T) {
+		trustBuiltIn(t, server.PublicKey())
+		drawn, _ := rng.GenerateSignedStrings(2, 4, "abc", true)
+		fetched, err := rng.GetResult(drawn.SerialNumber)
+		if err != nil || !reflect.DeepEqual(fetched, drawn) {
+			t.Errorf("fetched %+v, %v, drew %+v", fetched, err, drawn)
+		}
+
+		// a result altered on its way back no longer verifies
+		tampering := &http.Client{Transport: tamperingTransport{}}
+		tampered := TrueRNG("key", WithEndpoint(server.URL), WithRelease(4), WithHTTPClient(tampering))
+		if _, err := tampered.GetResult(drawn.SerialNumber); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("got %v", err)
+		}
+
+		randomOrgCertificate = []byte("no certificate here")
+		if _, err := rng.GetResult(drawn.SerialNumber); !errors.Is(err, ErrNoPublicKey) || errors.Is(err, ErrBadSignature) {
+			t.Errorf("without a key: got %v", err)
+		}
+	})
+
+	t.Run("User data is signed with the result", func(t *testing.T) {
+		verifier := NewVerifier(server.PublicKey())
+
+		type order struct {
+			ID    string `json:"id"`
+			Items int    `json:"items"`
+		}
+		drawn, err := rng.GenerateSignedStrings(1, 6, "abc", true, WithUserData(order{"A-17", 3}), WithLicenseData(map[string]string{"player": "x"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var echoed order
+		if err := json.Unmarshal(drawn.UserData, &echoed); err != nil || echoed != (order{"A-17", 3}) {
+			t.Errorf("got user data %s, %v", drawn.UserData, err)
+		}
+		if ok, err := verifier.Verify(drawn); !ok || err != nil {
+			t.Errorf("did not verify: %v", err)
+		}
+
+		forged := drawn
+		forged.Raw = json.RawMessage(bytes.Replace(drawn.Raw, []byte("A-17"), []byte("A-18"), 1))
+		if ok, _ := verifier.Verify(forged); ok {
+			t.Error("altered user data verified")
+		}
+
+		tooLong := strings.Repeat("x", 1000)
+		if _, err := rng.GenerateSignedIntegers(1, 1, 6, true, WithUserData(tooLong)); !errors.Is(err, ErrParameterOutOfRange) {
+			t.Errorf("expected oversized user data to be refused, got %v", err)
+		}
+	})
+
+	t.Run("Pregenerated randomizations repeat", func(t *testing.T) {
+		date := WithPregeneratedRandomization(PregeneratedRandomization{Date: "2018-01-01"})
+		first, err := TrueRNG("key", WithEndpoint(server.URL), WithRelease(4), date).GenerateIntegers(10, 1, 1000, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _ := TrueRNG("other", WithEndpoint(server.URL), WithRelease(4), date).GenerateIntegers(10, 1, 1000, true)
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("%v != %v", first, second)
+		}
+	})
+
+	t.Run("Invoke reaches any method", func(t *testing.T) {
+		var status Status
+		if err := rng.Invoke("getUsage", StatusReq{ApiKey: "key"}, &status); err != nil || status.Status != "running" {
+			t.Errorf("got %+v, %v", status, err)
+		}
+	})
+}
+
+// Alters every signed result on its way back, the way a proxy re-encoding JSON would.
+type tamperingTransport struct{}
+
+func (tamperingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	response, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.Replace(body, []byte(`"completionTime":`), []byte(`"completionTime": `), 1)
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	return response, nil
+}
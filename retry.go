@@ -0,0 +1,129 @@
+package caprice
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Describes how a client retries calls that fail for reasons likely to pass: timeouts, dropped
+// connections, 5xx responses and RANDOM.org reporting itself unavailable (see ErrServiceUnavailable).
+// Errors caused by the request itself, such as a bad parameter or an exhausted quota, are never retried.
+//
+// Calls that must not happen twice are retried more carefully: signed draws (the generateSigned*
+// methods, alone or in a batch), createTickets and revealTickets, however they are sent. A repeated
+// draw gets a new serial number, which would let a bad result be quietly redrawn, leave a gap in an
+// Archive, and fail outright on a ticket that has already been used. These calls are only retried when
+// the connection could not be made at all, so the request provably never reached the server, or when
+// RANDOM.org answered with a JSON-RPC error saying it is unavailable (code 100). A timeout, a dropped
+// connection or a 5xx status after the request was sent is returned as is, since a gateway may already
+// have passed the request on.
+//
+// Retries back off exponentially from `BaseDelay`, doubling after every attempt up to `MaxDelay`, with
+// random jitter so that many clients failing together don't retry in lockstep. Every attempt still
+// waits for the advisory delay like any other call.
+type RetryPolicy struct {
+	// Total number of attempts, the first one included. Values below 2 disable retries.
+	MaxAttempts int
+	// Delay before the first retry. Defaults to 500ms.
+	BaseDelay time.Duration
+	// Upper bound for any single delay. Defaults to 30s.
+	MaxDelay time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 500 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 30 * time.Second
+	}
+	return p
+}
+
+// The delay before the retry following `attempt`: half of it fixed, half of it random.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Runs `call` until it succeeds, fails for good, or runs out of attempts under the client's policy.
+// `unrepeatable` says whether `call` must not be made twice (see unrepeatable).
+func (rng trueRNG) retrying(ctx context.Context, unrepeatable bool, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= rng.retry.MaxAttempts || !retryable(ctx, err, unrepeatable) {
+			return err
+		}
+		delay := rng.retry.backoff(attempt)
+		rng.logger.InfoContext(ctx, logRetry, "attempt", attempt, "delay", delay, "error", rng.redact(err))
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Reports whether a call that failed with `err` is worth repeating. Failures caused by `ctx` itself
+// being done are final, as are HTTP statuses below 500. `unrepeatable` calls are only repeated if the
+// request was never sent, or RANDOM.org said it was unavailable.
+func retryable(ctx context.Context, err error, unrepeatable bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if unrepeatable {
+		var rpcError Error
+		if errors.As(err, &rpcError) && rpcError.Code == 100 {
+			return true
+		}
+		return neverSent(err)
+	}
+	if errors.Is(err, ErrServiceUnavailable) {
+		return true
+	}
+	var status StatusError
+	if errors.As(err, &status) {
+		return false
+	}
+	return errors.Is(err, ErrTransport)
+}
+
+// Reports whether `err` shows that no connection to the server was made, so it cannot have seen the
+// request: the name did not resolve, or dialling failed or was refused.
+func neverSent(err error) bool {
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return true
+	}
+	var opError *net.OpError
+	if errors.As(err, &opError) && opError.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// Reports whether `method` must not be repeated once the server may have carried it out: signed draws,
+// and the ticket methods that create or use up tickets.
+func unrepeatable(method string) bool {
+	return signedDraw(method) || method == "createTickets" || method == "revealTickets"
+}
+
+// Waits for `delay`, or until `ctx` is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
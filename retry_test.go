@@ -0,0 +1,140 @@
+package caprice
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestRetry(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	t.Run("Transient failures are retried", func(t *testing.T) {
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithRetry(policy))
+		server.InjectHTTPStatus(http.StatusServiceUnavailable)
+		server.InjectError(100, "The server is temporarily unavailable")
+		before := server.Calls("generateUUIDs")
+		if _, err := rng.GenerateUUIDs(1); err != nil {
+			t.Fatal(err)
+		}
+		if calls := server.Calls("generateUUIDs") - before; calls != 3 {
+			t.Errorf("expected 3 attempts, saw %d", calls)
+		}
+	})
+
+	t.Run("Attempts are bounded", func(t *testing.T) {
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithRetry(policy))
+		for i := 0; i < 3; i++ {
+			server.InjectHTTPStatus(http.StatusBadGateway)
+		}
+		if _, err := rng.GenerateUUIDs(1); !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("Request errors are final", func(t *testing.T) {
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithRetry(policy))
+		server.InjectError(403, "The operation requires 122 bits, but the API key only has 0 left")
+		before := server.Calls("generateUUIDs")
+		if _, err := rng.GenerateUUIDs(1); !errors.Is(err, ErrQuotaExhausted) {
+			t.Errorf("got %v", err)
+		}
+		if calls := server.Calls("generateUUIDs") - before; calls != 1 {
+			t.Errorf("expected 1 attempt, saw %d", calls)
+		}
+	})
+
+	t.Run("Signed draws are not repeated once sent", func(t *testing.T) {
+		// the server makes the draw, but the response is lost
+		lost := &failingTransport{err: errors.New("read: connection reset by peer"), send: true}
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithRetry(policy), WithHTTPClient(&http.Client{Transport: lost}))
+		before := server.Calls("generateSignedIntegers")
+		if _, err := rng.GenerateSignedIntegers(1, 1, 6, true); !errors.Is(err, ErrTransport) {
+			t.Errorf("got %v", err)
+		}
+		if calls := server.Calls("generateSignedIntegers") - before; calls != 1 || lost.requests != 1 {
+			t.Errorf("expected 1 draw, saw %d in %d attempts", calls, lost.requests)
+		}
+
+		// the same failure on an unsigned call is retried
+		lost.requests = 0
+		rng.GenerateIntegers(1, 1, 6, true)
+		if lost.requests != 3 {
+			t.Errorf("expected 3 attempts, saw %d", lost.requests)
+		}
+
+		// a refused connection never reached the server
+		refused := &failingTransport{err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+		rng = TrueRNG("key", WithEndpoint(server.URL), WithRetry(policy), WithHTTPClient(&http.Client{Transport: refused}))
+		rng.GenerateSignedIntegers(1, 1, 6, true)
+		if refused.requests != 3 {
+			t.Errorf("expected 3 attempts, saw %d", refused.requests)
+		}
+	})
+
+	t.Run("Signed draws are not repeated after a 5xx", func(t *testing.T) {
+		rng := TrueRNG("key", WithEndpoint(server.URL), WithRetry(policy), WithRelease(4))
+		server.InjectHTTPStatus(http.StatusBadGateway)
+		before := server.Calls("generateSignedIntegers")
+		if _, err := rng.GenerateSignedIntegers(1, 1, 6, true); !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("got %v", err)
+		}
+		if calls := server.Calls("generateSignedIntegers") - before; calls != 1 {
+			t.Errorf("expected 1 attempt, saw %d", calls)
+		}
+
+		server.InjectHTTPStatus(http.StatusGatewayTimeout)
+		before = server.Calls("createTickets")
+		if err := rng.Invoke("createTickets", CreateTicketsReq{ApiKey: "key", N: 1}, nil); !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("got %v", err)
+		}
+		if calls := server.Calls("createTickets") - before; calls != 1 {
+			t.Errorf("expected 1 attempt, saw %d", calls)
+		}
+
+		// RANDOM.org saying it is unavailable means it did not draw
+		server.InjectError(100, "The server is temporarily unavailable")
+		before = server.Calls("generateSignedIntegers")
+		if _, err := rng.GenerateSignedIntegers(1, 1, 6, true); err != nil {
+			t.Error(err)
+		}
+		if calls := server.Calls("generateSignedIntegers") - before; calls != 2 {
+			t.Errorf("expected 2 attempts, saw %d", calls)
+		}
+	})
+
+	t.Run("Backoff grows up to the maximum", func(t *testing.T) {
+		policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}.withDefaults()
+		if delay := policy.backoff(1); delay < 50*time.Millisecond || delay > 100*time.Millisecond {
+			t.Errorf("first backoff %v", delay)
+		}
+		if delay := policy.backoff(10); delay < 500*time.Millisecond || delay > time.Second {
+			t.Errorf("tenth backoff %v", delay)
+		}
+	})
+}
+
+// Fails every request with `err`, after passing it on to the server if `send` is set.
+type failingTransport struct {
+	err      error
+	send     bool
+	requests int
+}
+
+func (f *failingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	f.requests++
+	if f.send {
+		if response, err := http.DefaultTransport.RoundTrip(r); err == nil {
+			response.Body.Close()
+		}
+	}
+	return nil, f.err
+}
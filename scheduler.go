@@ -0,0 +1,78 @@
+package caprice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Enforces RANDOM.org's usage guidelines for a single client: only one request is in flight at a
+// time, and no request is sent before the advisory delay returned by the previous one has elapsed.
+// Every copy of a trueRNG object shares the same scheduler, so this holds across goroutines.
+type scheduler struct {
+	// a semaphore of capacity one; holding it means owning the right to talk to the server
+	slot     chan struct{}
+	next     time.Time
+	failFast bool
+	logger   *slog.Logger
+}
+
+func newScheduler(failFast bool, logger *slog.Logger) *scheduler {
+	return &scheduler{slot: make(chan struct{}, 1), failFast: failFast, logger: logger}
+}
+
+// Blocks until it is our turn to send a request and any advisory delay has passed. If the scheduler
+// was configured to fail fast, an error is returned instead of waiting. Callers that succeed must call
+// release once their request completes.
+func (s *scheduler) acquire(ctx context.Context) error {
+
+	if s.failFast {
+		select {
+		case s.slot <- struct{}{}:
+		default:
+			return fmt.Errorf("%w: another request is in flight on this client", ErrWouldBlock)
+		}
+		if wait := time.Until(s.next); wait > 0 {
+			<-s.slot
+			return fmt.Errorf("%w: advisory delay has not elapsed; retry in %v", ErrWouldBlock, wait)
+		}
+		return nil
+	}
+
+	select {
+	case s.slot <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if wait := time.Until(s.next); wait > 0 {
+		s.logger.InfoContext(ctx, logAdvisoryDelay, "wait", wait)
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			<-s.slot
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Records the advisory delay the server asked for and hands the turn to the next request.
+func (s *scheduler) release(delay time.Duration) {
+	s.next = time.Now().Add(delay)
+	<-s.slot
+}
+
+// Extracts the advisory delay, given in milliseconds, from a raw result. Results without one
+// (e.g. from getUsage) impose no delay.
+func advisoryDelay(result json.RawMessage) time.Duration {
+	var delay struct {
+		AdvisoryDelay int `json:"advisoryDelay"`
+	}
+	json.Unmarshal(result, &delay)
+	return time.Duration(delay.AdvisoryDelay) * time.Millisecond
+}
@@ -0,0 +1,90 @@
+package caprice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestIntegerSequences(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL))
+
+	// a lottery line: five main numbers and two stars
+	lengths, mins, maxes := []int{5, 2}, []int{1, 1}, []int{50, 12}
+	replacement := []bool{false, false}
+
+	check := func(t *testing.T, sequences [][]int) {
+		if len(sequences) != 2 || len(sequences[0]) != 5 || len(sequences[1]) != 2 {
+			t.Fatalf("got %v", sequences)
+		}
+		for i, sequence := range sequences {
+			seen := map[int]bool{}
+			for _, value := range sequence {
+				if value < mins[i] || value > maxes[i] || seen[value] {
+					t.Errorf("sequence %d: bad draw %v", i, sequence)
+				}
+				seen[value] = true
+			}
+		}
+	}
+
+	t.Run("Basic", func(t *testing.T) {
+		sequences, err := rng.GenerateIntegerSequences(lengths, mins, maxes, replacement, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, sequences)
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		result, err := rng.GenerateIntegerSequencesRaw(lengths, mins, maxes, replacement, []int{16, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := result.Random.Data[1].([]interface{})[0].(string); !ok {
+			t.Errorf("expected strings in base 2, got %v", result.Random.Data)
+		}
+		if result.BitsUsed != (IntegerSequencesReq{N: 2, Length: lengths, Min: mins, Max: maxes}).EstimatedBits() {
+			t.Errorf("charged %d bits", result.BitsUsed)
+		}
+	})
+
+	t.Run("Signed", func(t *testing.T) {
+		signed, err := rng.GenerateSignedIntegerSequences(lengths, mins, maxes, replacement, []int{10, 16})
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, signed.Data)
+		if ok, err := NewVerifier(server.PublicKey()).Verify(signed); !ok || err != nil {
+			t.Errorf("did not verify: %v", err)
+		}
+	})
+
+	t.Run("Batched", func(t *testing.T) {
+		batch := rng.NewBatch()
+		call := batch.GenerateIntegerSequences(lengths, mins, maxes, replacement, []int{8, 8})
+		if err := batch.Do(); err != nil {
+			t.Fatal(err)
+		}
+		sequences, err := call.IntegerSequences()
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, sequences)
+	})
+
+	t.Run("Mismatched or impossible sequences are refused", func(t *testing.T) {
+		if _, err := rng.GenerateIntegerSequences([]int{5}, mins, maxes, replacement, nil); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("got %v", err)
+		}
+		var parameterError ParameterError
+		_, err := rng.GenerateIntegerSequences([]int{5, 13}, mins, maxes, replacement, nil)
+		if !errors.As(err, &parameterError) || parameterError.Field != "length" {
+			t.Errorf("got %v", err)
+		}
+	})
+}
@@ -1,19 +1,25 @@
 package caprice
 
 import (
+	"context"
 	"encoding/json"
-	"log"
 )
 
 // Generate `n` random integers between `min` and `max`.
 // If `replacement` is true, pick random numbers with replacement. Default is false.
-// We do not support base selection, since it is easy to format into the base of your choice from base 10
-func (rng trueRNG) GenerateSignedIntegers(n, min, max int, replacement bool) (SignedIntegerData, Error) {
+// To have RANDOM.org draw them in another base, see GenerateSignedIntegersInBase.
+func (rng trueRNG) GenerateSignedIntegers(n, min, max int, replacement bool, options ...SignedOption) (SignedIntegerData, error) {
+	return rng.GenerateSignedIntegersContext(context.Background(), n, min, max, replacement, options...)
+}
+
+// Same as GenerateSignedIntegers, but bound to `ctx`.
+func (rng trueRNG) GenerateSignedIntegersContext(ctx context.Context, n, min, max int, replacement bool, options ...SignedOption) (SignedIntegerData, error) {
 
-	body := IntegersReq{ApiKey: rng.apiKey, N: n, Min: min, Max: max, Replacement: replacement}
-	result, err := SignedRequest("generateSignedIntegers", body)
+	body := IntegersReq{ApiKey: rng.apiKey, N: n, Min: min, Max: max, Replacement: replacement,
+		Release4Params: rng.signedParams(options)}
+	result, err := rng.SignedRequestContext(ctx, "generateSignedIntegers", body)
 
-	if err.Message != "" {
+	if err != nil {
 		return SignedIntegerData{}, err
 	}
 
@@ -21,10 +27,47 @@ func (rng trueRNG) GenerateSignedIntegers(n, min, max int, replacement bool) (Si
 	randomData := Random{}
 	json.Unmarshal(signedResult.Raw, &randomData)
 
-	data := randomData.Data
-	intArray := make([]int, len(data))
-	for i, num := range data {
-		intArray[i] = int(num.(float64))
+	intArray, err := decodeIntegers(randomData.Data, 10)
+	if err != nil {
+		return SignedIntegerData{}, err
+	}
+
+	return SignedIntegerData{
+		Data:         intArray,
+		Raw:          signedResult.Raw,
+		HashedApiKey: randomData.HashedApiKey,
+		SerialNumber: randomData.SerialNumber,
+		TicketData:   randomData.TicketData,
+		UserData:     randomData.UserData,
+		Signature:    signedResult.Signature,
+	}, nil
+}
+
+// Generate `n` random integers between `min` and `max`, drawn in `base` (2, 8, 10 or 16), along with
+// RANDOM.org's signature. Data holds the integers decoded; Raw keeps the strings exactly as signed.
+// If `replacement` is true, pick random numbers with replacement.
+func (rng trueRNG) GenerateSignedIntegersInBase(n, min, max int, replacement bool, base int, options ...SignedOption) (SignedIntegerData, error) {
+	return rng.GenerateSignedIntegersInBaseContext(context.Background(), n, min, max, replacement, base, options...)
+}
+
+// Same as GenerateSignedIntegersInBase, but bound to `ctx`.
+func (rng trueRNG) GenerateSignedIntegersInBaseContext(ctx context.Context, n, min, max int, replacement bool, base int, options ...SignedOption) (SignedIntegerData, error) {
+
+	body := IntegersReq{ApiKey: rng.apiKey, N: n, Min: min, Max: max, Replacement: replacement, Base: base,
+		Release4Params: rng.signedParams(options)}
+	result, err := rng.SignedRequestContext(ctx, "generateSignedIntegers", body)
+
+	if err != nil {
+		return SignedIntegerData{}, err
+	}
+
+	signedResult, _ := result.Content().(SignedResult)
+	randomData := Random{}
+	json.Unmarshal(signedResult.Raw, &randomData)
+
+	intArray, err := decodeIntegers(randomData.Data, base)
+	if err != nil {
+		return SignedIntegerData{}, err
 	}
 
 	return SignedIntegerData{
@@ -32,18 +75,63 @@ func (rng trueRNG) GenerateSignedIntegers(n, min, max int, replacement bool) (Si
 		Raw:          signedResult.Raw,
 		HashedApiKey: randomData.HashedApiKey,
 		SerialNumber: randomData.SerialNumber,
+		TicketData:   randomData.TicketData,
+		UserData:     randomData.UserData,
 		Signature:    signedResult.Signature,
-	}, Error{}
+	}, nil
+}
+
+// Generate integer sequences as GenerateIntegerSequences does, along with RANDOM.org's signature. Data
+// holds the sequences decoded; Raw keeps them exactly as signed.
+func (rng trueRNG) GenerateSignedIntegerSequences(lengths, mins, maxes []int, replacement []bool, bases []int, options ...SignedOption) (SignedIntegerSequenceData, error) {
+	return rng.GenerateSignedIntegerSequencesContext(context.Background(), lengths, mins, maxes, replacement, bases, options...)
+}
+
+// Same as GenerateSignedIntegerSequences, but bound to `ctx`.
+func (rng trueRNG) GenerateSignedIntegerSequencesContext(ctx context.Context, lengths, mins, maxes []int, replacement []bool, bases []int, options ...SignedOption) (SignedIntegerSequenceData, error) {
+
+	body := IntegerSequencesReq{ApiKey: rng.apiKey, N: len(lengths), Length: lengths, Min: mins, Max: maxes,
+		Replacement: replacement, Base: bases, Release4Params: rng.signedParams(options)}
+	result, err := rng.SignedRequestContext(ctx, "generateSignedIntegerSequences", body)
+
+	if err != nil {
+		return SignedIntegerSequenceData{}, err
+	}
+
+	signedResult, _ := result.Content().(SignedResult)
+	randomData := Random{}
+	json.Unmarshal(signedResult.Raw, &randomData)
+
+	sequences, err := decodeIntegerSequences(randomData.Data, bases)
+	if err != nil {
+		return SignedIntegerSequenceData{}, err
+	}
+
+	return SignedIntegerSequenceData{
+		Data:         sequences,
+		Raw:          signedResult.Raw,
+		HashedApiKey: randomData.HashedApiKey,
+		SerialNumber: randomData.SerialNumber,
+		TicketData:   randomData.TicketData,
+		UserData:     randomData.UserData,
+		Signature:    signedResult.Signature,
+	}, nil
 }
 
 // Generate `n` random decimal fractions with precision upto `decimalPlaces`.
 // If `replacement` is true, pick random numbers with replacement. Default is false.
-func (rng trueRNG) GenerateSignedDecimalFractions(n, decimalPlaces int, replacement bool) (SignedFloatData, Error) {
+func (rng trueRNG) GenerateSignedDecimalFractions(n, decimalPlaces int, replacement bool, options ...SignedOption) (SignedFloatData, error) {
+	return rng.GenerateSignedDecimalFractionsContext(context.Background(), n, decimalPlaces, replacement, options...)
+}
+
+// Same as GenerateSignedDecimalFractions, but bound to `ctx`.
+func (rng trueRNG) GenerateSignedDecimalFractionsContext(ctx context.Context, n, decimalPlaces int, replacement bool, options ...SignedOption) (SignedFloatData, error) {
 
-	body := DecimalFractionsReq{ApiKey: rng.apiKey, N: n, DecimalPlaces: decimalPlaces, Replacement: replacement}
-	result, err := SignedRequest("generateSignedDecimalFractions", body)
+	body := DecimalFractionsReq{ApiKey: rng.apiKey, N: n, DecimalPlaces: decimalPlaces, Replacement: replacement,
+		Release4Params: rng.signedParams(options)}
+	result, err := rng.SignedRequestContext(ctx, "generateSignedDecimalFractions", body)
 
-	if err.Message != "" {
+	if err != nil {
 		return SignedFloatData{}, err
 	}
 
@@ -63,20 +151,27 @@ func (rng trueRNG) GenerateSignedDecimalFractions(n, decimalPlaces int, replacem
 		Raw:          signedResult.Raw,
 		HashedApiKey: randomData.HashedApiKey,
 		SerialNumber: randomData.SerialNumber,
+		TicketData:   randomData.TicketData,
+		UserData:     randomData.UserData,
 		Signature:    signedResult.Signature,
-	}, Error{}
+	}, nil
 }
 
 // Generate `n` Gaussians from a distribution with mean `mean` and stdev `standardDeviation`, returned with
 // at most `significantDigits` sig. digits.
 // If `replacement` is true, pick random numbers with replacement. Default is false.
-func (rng trueRNG) GenerateSignedGaussians(n int, mean, standardDeviation float64, significantDigits int) (SignedFloatData, Error) {
+func (rng trueRNG) GenerateSignedGaussians(n int, mean, standardDeviation float64, significantDigits int, options ...SignedOption) (SignedFloatData, error) {
+	return rng.GenerateSignedGaussiansContext(context.Background(), n, mean, standardDeviation, significantDigits, options...)
+}
+
+// Same as GenerateSignedGaussians, but bound to `ctx`.
+func (rng trueRNG) GenerateSignedGaussiansContext(ctx context.Context, n int, mean, standardDeviation float64, significantDigits int, options ...SignedOption) (SignedFloatData, error) {
 
 	body := GaussiansReq{ApiKey: rng.apiKey, N: n, Mean: mean, StandardDeviation: standardDeviation,
-		SignificantDigits: significantDigits}
-	result, err := SignedRequest("generateSignedGaussians", body)
+		SignificantDigits: significantDigits, Release4Params: rng.signedParams(options)}
+	result, err := rng.SignedRequestContext(ctx, "generateSignedGaussians", body)
 
-	if err.Message != "" {
+	if err != nil {
 		return SignedFloatData{}, err
 	}
 
@@ -95,18 +190,25 @@ func (rng trueRNG) GenerateSignedGaussians(n int, mean, standardDeviation float6
 		Raw:          signedResult.Raw,
 		HashedApiKey: randomData.HashedApiKey,
 		SerialNumber: randomData.SerialNumber,
+		TicketData:   randomData.TicketData,
+		UserData:     randomData.UserData,
 		Signature:    signedResult.Signature,
-	}, Error{}
+	}, nil
 }
 
 // Generate `n` random strings with precision upto `decimalPlaces`.
-func (rng trueRNG) GenerateSignedStrings(n, length int, characters string, replacement bool) (SignedStringData, Error) {
+func (rng trueRNG) GenerateSignedStrings(n, length int, characters string, replacement bool, options ...SignedOption) (SignedStringData, error) {
+	return rng.GenerateSignedStringsContext(context.Background(), n, length, characters, replacement, options...)
+}
+
+// Same as GenerateSignedStrings, but bound to `ctx`.
+func (rng trueRNG) GenerateSignedStringsContext(ctx context.Context, n, length int, characters string, replacement bool, options ...SignedOption) (SignedStringData, error) {
 
 	body := StringsReq{ApiKey: rng.apiKey, N: n, Length: length, Characters: characters,
-		Replacement: replacement}
-	result, err := SignedRequest("generateSignedStrings", body)
+		Replacement: replacement, Release4Params: rng.signedParams(options)}
+	result, err := rng.SignedRequestContext(ctx, "generateSignedStrings", body)
 
-	if err.Message != "" {
+	if err != nil {
 		return SignedStringData{}, err
 	}
 
@@ -125,76 +227,96 @@ func (rng trueRNG) GenerateSignedStrings(n, length int, characters string, repla
 		Raw:          signedResult.Raw,
 		HashedApiKey: randomData.HashedApiKey,
 		SerialNumber: randomData.SerialNumber,
+		TicketData:   randomData.TicketData,
+		UserData:     randomData.UserData,
 		Signature:    signedResult.Signature,
-	}, Error{}
+	}, nil
 }
 
-// Generate `n` random strings with precision upto `decimalPlaces`.
-func (rng trueRNG) GenerateSignedUUIDs(n int) (SignedStringData, Error) {
+// Generate `n` random version 4 UUIDs. Every value received is checked to be one.
+func (rng trueRNG) GenerateSignedUUIDs(n int, options ...SignedOption) (SignedUUIDData, error) {
+	return rng.GenerateSignedUUIDsContext(context.Background(), n, options...)
+}
 
-	body := UUIDsReq{ApiKey: rng.apiKey, N: n}
-	result, err := SignedRequest("generateSignedUUIDs", body)
+// Same as GenerateSignedUUIDs, but bound to `ctx`.
+func (rng trueRNG) GenerateSignedUUIDsContext(ctx context.Context, n int, options ...SignedOption) (SignedUUIDData, error) {
 
-	if err.Message != "" {
-		return SignedStringData{}, err
+	body := UUIDsReq{ApiKey: rng.apiKey, N: n, Release4Params: rng.signedParams(options)}
+	result, err := rng.SignedRequestContext(ctx, "generateSignedUUIDs", body)
+
+	if err != nil {
+		return SignedUUIDData{}, err
 	}
 
 	signedResult, _ := result.Content().(SignedResult)
 	randomData := Random{}
 	json.Unmarshal(signedResult.Raw, &randomData)
 
-	data := randomData.Data
-	stringArray := make([]string, len(data))
-	for i, string_ := range data {
-		stringArray[i] = string(string_.(string))
+	uuids, err := decodeUUIDs(randomData.Data)
+	if err != nil {
+		return SignedUUIDData{}, err
 	}
 
-	return SignedStringData{
-		Data:         stringArray,
+	return SignedUUIDData{
+		Data:         uuids,
 		Raw:          signedResult.Raw,
 		HashedApiKey: randomData.HashedApiKey,
 		SerialNumber: randomData.SerialNumber,
+		TicketData:   randomData.TicketData,
+		UserData:     randomData.UserData,
 		Signature:    signedResult.Signature,
-	}, Error{}
+	}, nil
 }
 
-// Generate `n` random blobs of length `size`, formatted in `format` (either base64 or hex)
-func (rng trueRNG) GenerateSignedBlobs(n, size int, format string) (SignedStringData, Error) {
+// Generate `n` random blobs of `size`, formatted in `format` (Base64 or Hex). Data holds the blobs
+// decoded; Raw keeps them encoded, exactly as signed.
+func (rng trueRNG) GenerateSignedBlobs(n int, size BlobSize, format BlobFormat, options ...SignedOption) (SignedBlobData, error) {
+	return rng.GenerateSignedBlobsContext(context.Background(), n, size, format, options...)
+}
 
-	body := BlobsReq{ApiKey: rng.apiKey, N: n, Size: size, Format: format}
-	result, err := SignedRequest("generateSignedBlobs", body)
+// Same as GenerateSignedBlobs, but bound to `ctx`.
+func (rng trueRNG) GenerateSignedBlobsContext(ctx context.Context, n int, size BlobSize, format BlobFormat, options ...SignedOption) (SignedBlobData, error) {
 
-	if err.Message != "" {
-		return SignedStringData{}, err
+	body := BlobsReq{ApiKey: rng.apiKey, N: n, Size: size, Format: format,
+		Release4Params: rng.signedParams(options)}
+	result, err := rng.SignedRequestContext(ctx, "generateSignedBlobs", body)
+
+	if err != nil {
+		return SignedBlobData{}, err
 	}
 
 	signedResult, _ := result.Content().(SignedResult)
 	randomData := Random{}
 	json.Unmarshal(signedResult.Raw, &randomData)
 
-	data := randomData.Data
-	stringArray := make([]string, len(data))
-	for i, string_ := range data {
-		stringArray[i] = string(string_.(string))
+	blobs, err := decodeBlobs(randomData.Data, format)
+	if err != nil {
+		return SignedBlobData{}, err
 	}
 
-	return SignedStringData{
-		Data:         stringArray,
+	return SignedBlobData{
+		Data:         blobs,
 		Raw:          signedResult.Raw,
 		HashedApiKey: randomData.HashedApiKey,
 		SerialNumber: randomData.SerialNumber,
+		TicketData:   randomData.TicketData,
+		UserData:     randomData.UserData,
 		Signature:    signedResult.Signature,
-	}, Error{}
+	}, nil
 }
 
-// Currently broken - API consistently claims authenticity is false.
+// Unreliable - `random` has to be re-encoded before it is sent back, so the API may not see the bytes it
+// signed. Prefer Verifier, which checks the signature locally on the original bytes.
 //
 // This method verifies that received random data actually originates from RANDOM.org, given a raw `random`
-// JSON that is exactly what is given to you by Signed<Int|Float|String>Data.Raw and a `signature`, also contained
-// in Signed<Int|Float|String>Data.Signature.
-func (rng trueRNG) VerifySignature(random json.RawMessage, signature string) (bool, Error) {
+// JSON that is exactly what is given to you by the Raw field of any Signed*Data and a `signature`, also contained
+// in its Signature field.
+func (rng trueRNG) VerifySignature(random json.RawMessage, signature string) (bool, error) {
+	return rng.VerifySignatureContext(context.Background(), random, signature)
+}
 
-	log.Print("Method VerifySignature is currently broken and under active maintenance. Do not expect accurate results.")
+// Same as VerifySignature, but bound to `ctx`.
+func (rng trueRNG) VerifySignatureContext(ctx context.Context, random json.RawMessage, signature string) (bool, error) {
 
 	// it turns out json.RawMessage does not survive across multiple marshalings. Our random data is
 	// interpreted as a byte array, and its base64 encoding is sent along. To prevent this, we unmarshal
@@ -203,12 +325,12 @@ func (rng trueRNG) VerifySignature(random json.RawMessage, signature string) (bo
 	json.Unmarshal(random, &object)
 
 	body := VerifySignatureReq{Raw: object, Signature: signature}
-	result, err := SignedRequest("verifySignature", body)
+	result, err := rng.SignedRequestContext(ctx, "verifySignature", body)
 
-	if err.Message != "" {
+	if err != nil {
 		return false, err
 	}
 
-	return result.(VerifiedSignature).Authenticity, Error{}
+	return result.(VerifiedSignature).Authenticity, nil
 
 }
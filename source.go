@@ -0,0 +1,108 @@
+package caprice
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	randv2 "math/rand/v2"
+	"sync"
+)
+
+// What a Source does when RANDOM.org cannot supply more bytes, e.g. because the quota is exhausted. The
+// zero value is FallbackCrypto; a Source only panics if asked to with FallbackPanic.
+type Fallback int
+
+const (
+	// Continue with bytes from crypto/rand.
+	FallbackCrypto Fallback = iota
+	// Continue with a math/rand generator seeded from crypto/rand.
+	FallbackPseudo
+	// Panic with the error that stopped RANDOM.org from supplying bytes.
+	FallbackPanic
+)
+
+// A math/rand Source64 and math/rand/v2 Source fed by RANDOM.org. Values come out of a Reader, so they
+// are drawn in bulk and most calls are served from memory. Once the Reader fails, the Source switches to
+// its Fallback for good; Err reports why. A Source is safe for concurrent use and must be closed.
+//
+//	source := rng.NewSource(caprice.ReaderOptions{}, caprice.FallbackCrypto)
+//	defer source.Close()
+//	r := rand.New(source)
+type Source struct {
+	reader   *Reader
+	fallback Fallback
+
+	mu     sync.Mutex
+	err    error
+	pseudo *rand.Rand
+}
+
+var (
+	_ rand.Source64 = (*Source)(nil)
+	_ randv2.Source = (*Source)(nil)
+)
+
+// A helper function that returns a Source drawing on this client through a Reader tuned by `options`.
+func (rng trueRNG) NewSource(options ReaderOptions, fallback Fallback) *Source {
+	return &Source{reader: rng.NewReader(options), fallback: fallback}
+}
+
+// Returns 64 random bits.
+func (s *Source) Uint64() uint64 {
+	var buffer [8]byte
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err == nil {
+		_, err := io.ReadFull(s.reader, buffer[:])
+		if err == nil {
+			return binary.LittleEndian.Uint64(buffer[:])
+		}
+		s.err = err
+		s.reader.Close()
+	}
+
+	switch s.fallback {
+	case FallbackPanic:
+		panic(s.err)
+	case FallbackPseudo:
+		if s.pseudo == nil {
+			s.pseudo = rand.New(rand.NewSource(int64(seed())))
+		}
+		return s.pseudo.Uint64()
+	default:
+		return cryptoSource{}.Uint64()
+	}
+}
+
+// A seed for the pseudo fallback, from crypto/rand or, should that fail, the runtime's own randomly
+// seeded generator, so that a failing crypto/rand never leaves every Source on the same sequence.
+func seed() uint64 {
+	var seed [8]byte
+	if _, err := cryptorand.Read(seed[:]); err != nil {
+		return randv2.Uint64()
+	}
+	return binary.LittleEndian.Uint64(seed[:])
+}
+
+// Returns a non-negative random int64.
+func (s *Source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Does nothing: true random numbers cannot be seeded. It exists to satisfy rand.Source.
+func (s *Source) Seed(int64) {}
+
+// The error that made the Source switch to its fallback, or nil while RANDOM.org is still in use.
+func (s *Source) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Stops the underlying Reader. Calls after Close are served by the fallback.
+func (s *Source) Close() error {
+	return s.reader.Close()
+}
@@ -0,0 +1,80 @@
+package caprice
+
+import (
+	"errors"
+	"math/rand"
+	randv2 "math/rand/v2"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestSource(t *testing.T) {
+
+	t.Run("Drives math/rand and math/rand/v2", func(t *testing.T) {
+		server := randomtest.NewServer()
+		defer server.Close()
+		source := TrueRNG("key", WithEndpoint(server.URL)).NewSource(ReaderOptions{PoolSize: 512}, FallbackPanic)
+		defer source.Close()
+
+		seen := map[int]bool{}
+		r := rand.New(source)
+		for i := 0; i < 100; i++ {
+			seen[r.Intn(1000)] = true
+		}
+		if len(seen) < 80 {
+			t.Errorf("only %d distinct values in 100 draws", len(seen))
+		}
+		if value := randv2.New(source).IntN(10); value < 0 || value >= 10 {
+			t.Errorf("IntN(10) returned %d", value)
+		}
+		if source.Err() != nil {
+			t.Error(source.Err())
+		}
+	})
+
+	t.Run("Falls back once the quota runs out", func(t *testing.T) {
+		server := randomtest.NewServer(randomtest.WithQuota(64, 100))
+		defer server.Close()
+		source := TrueRNG("key", WithEndpoint(server.URL)).NewSource(ReaderOptions{}, FallbackCrypto)
+		defer source.Close()
+
+		for i := 0; i < 4; i++ {
+			source.Uint64()
+		}
+		if !errors.Is(source.Err(), ErrQuotaExhausted) {
+			t.Errorf("got %v", source.Err())
+		}
+	})
+
+	t.Run("Neither the zero Fallback nor FallbackPseudo panics", func(t *testing.T) {
+		for _, fallback := range []Fallback{Fallback(0), FallbackPseudo} {
+			server := randomtest.NewServer(randomtest.WithQuota(0, 100))
+			source := TrueRNG("key", WithEndpoint(server.URL)).NewSource(ReaderOptions{}, fallback)
+
+			seen := map[uint64]bool{}
+			for i := 0; i < 10; i++ {
+				seen[source.Uint64()] = true
+			}
+			if len(seen) < 10 || !errors.Is(source.Err(), ErrQuotaExhausted) {
+				t.Errorf("fallback %d: %d distinct values, error %v", fallback, len(seen), source.Err())
+			}
+			source.Close()
+			server.Close()
+		}
+	})
+
+	t.Run("Panics only with FallbackPanic", func(t *testing.T) {
+		server := randomtest.NewServer(randomtest.WithQuota(0, 100))
+		defer server.Close()
+		source := TrueRNG("key", WithEndpoint(server.URL)).NewSource(ReaderOptions{}, FallbackPanic)
+		defer source.Close()
+
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrQuotaExhausted) {
+				t.Errorf("expected a quota panic, got %v", err)
+			}
+		}()
+		source.Int63()
+	})
+}
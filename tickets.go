@@ -0,0 +1,158 @@
+package caprice
+
+import "context"
+
+// Adjusts the release 4 parameters of a single signed request. Pass any number of them to a
+// GenerateSigned* method.
+type SignedOption func(*Release4Params)
+
+// Draw with the ticket `ticketId`, obtained from CreateTickets. A ticket can only be used once, so the
+// draw it records cannot be repeated until a favourable result comes up. Requires release 4.
+func WithTicketID(ticketId string) SignedOption {
+	return func(p *Release4Params) {
+		p.TicketId = ticketId
+	}
+}
+
+// Attach `userData` to the draw, e.g. the order ID it decides. It can be any value that encodes to at
+// most 1000 characters of JSON. RANDOM.org echoes it back inside the signed `random` object, so the
+// signature binds it to the result; it is returned as the result's UserData. Requires release 4.
+func WithUserData(userData interface{}) SignedOption {
+	return func(p *Release4Params) {
+		p.UserData = userData
+	}
+}
+
+// Draw under the license described by `licenseData`, for keys whose license requires it, e.g. to name
+// the user a draw is made on behalf of. Requires release 4.
+func WithLicenseData(licenseData interface{}) SignedOption {
+	return func(p *Release4Params) {
+		p.LicenseData = licenseData
+	}
+}
+
+// The release 4 parameters of a signed request: the client's own, adjusted by `options`.
+func (rng trueRNG) signedParams(options []SignedOption) Release4Params {
+	params := rng.release4Params()
+	for _, option := range options {
+		option(&params)
+	}
+	return params
+}
+
+// Where a ticket sits in its chain. When a ticket is used, RANDOM.org creates its successor, so that a
+// series of draws can be published as a chain nobody can quietly cut short or fork.
+type TicketData struct {
+	TicketId         string `json:"ticketId"`
+	PreviousTicketId string `json:"previousTicketId"`
+	NextTicketId     string `json:"nextTicketId"`
+}
+
+// A ticket as RANDOM.org describes it. Unused tickets have an empty UsedTime; Result is only filled in
+// by GetTicket, once the ticket is used and its result is shown.
+type Ticket struct {
+	TicketId         string        `json:"ticketId"`
+	HashedApiKey     string        `json:"hashedApiKey"`
+	ShowResult       bool          `json:"showResult"`
+	CreationTime     string        `json:"creationTime"`
+	UsedTime         string        `json:"usedTime"`
+	SerialNumber     int           `json:"serialNumber"`
+	ExpirationTime   string        `json:"expirationTime"`
+	PreviousTicketId string        `json:"previousTicketId"`
+	NextTicketId     string        `json:"nextTicketId"`
+	Result           *SignedResult `json:"result,omitempty"`
+}
+
+// Which tickets ListTickets returns, by their place in a chain.
+type TicketType string
+
+const (
+	// Tickets with neither a predecessor nor a successor, i.e. unused ones.
+	SingletonTickets TicketType = "singleton"
+	// The first ticket of each chain.
+	HeadTickets TicketType = "head"
+	// The last ticket of each chain, i.e. the one to draw with next.
+	TailTickets TicketType = "tail"
+)
+
+type CreateTicketsReq struct {
+	ApiKey     string `json:"apiKey"`
+	N          int    `json:"n"`
+	ShowResult bool   `json:"showResult"`
+}
+
+type RevealTicketsReq struct {
+	ApiKey   string `json:"apiKey"`
+	TicketId string `json:"ticketId"`
+}
+
+type ListTicketsReq struct {
+	ApiKey     string     `json:"apiKey"`
+	TicketType TicketType `json:"ticketType"`
+}
+
+type GetTicketReq struct {
+	TicketId string `json:"ticketId"`
+}
+
+// Create `n` tickets to draw with later, e.g. to publish them before a giveaway. If `showResult` is
+// true, anybody holding a ticket's id can see the result it was used for with GetTicket; otherwise that
+// only becomes possible once RevealTickets is called. Requires release 4.
+func (rng trueRNG) CreateTickets(n int, showResult bool) ([]Ticket, error) {
+	return rng.CreateTicketsContext(context.Background(), n, showResult)
+}
+
+// Same as CreateTickets, but bound to `ctx`.
+func (rng trueRNG) CreateTicketsContext(ctx context.Context, n int, showResult bool) ([]Ticket, error) {
+	tickets := []Ticket{}
+	if err := rng.InvokeContext(ctx, "createTickets", CreateTicketsReq{ApiKey: rng.apiKey, N: n, ShowResult: showResult}, &tickets); err != nil {
+		return []Ticket{}, err
+	}
+	return tickets, nil
+}
+
+// Show the results of the ticket `ticketId` and of every ticket before it in its chain. Returns the
+// number of tickets that were revealed. Requires release 4.
+func (rng trueRNG) RevealTickets(ticketId string) (int, error) {
+	return rng.RevealTicketsContext(context.Background(), ticketId)
+}
+
+// Same as RevealTickets, but bound to `ctx`.
+func (rng trueRNG) RevealTicketsContext(ctx context.Context, ticketId string) (int, error) {
+	var revealed struct {
+		TicketCount int `json:"ticketCount"`
+	}
+	if err := rng.InvokeContext(ctx, "revealTickets", RevealTicketsReq{ApiKey: rng.apiKey, TicketId: ticketId}, &revealed); err != nil {
+		return 0, err
+	}
+	return revealed.TicketCount, nil
+}
+
+// List this API key's tickets of `ticketType`. Requires release 4.
+func (rng trueRNG) ListTickets(ticketType TicketType) ([]Ticket, error) {
+	return rng.ListTicketsContext(context.Background(), ticketType)
+}
+
+// Same as ListTickets, but bound to `ctx`.
+func (rng trueRNG) ListTicketsContext(ctx context.Context, ticketType TicketType) ([]Ticket, error) {
+	tickets := []Ticket{}
+	if err := rng.InvokeContext(ctx, "listTickets", ListTicketsReq{ApiKey: rng.apiKey, TicketType: ticketType}, &tickets); err != nil {
+		return []Ticket{}, err
+	}
+	return tickets, nil
+}
+
+// Look up the ticket `ticketId`, along with its result if it has been used and shown. This works for
+// anybody's tickets, which is how participants check a draw. Requires release 4.
+func (rng trueRNG) GetTicket(ticketId string) (Ticket, error) {
+	return rng.GetTicketContext(context.Background(), ticketId)
+}
+
+// Same as GetTicket, but bound to `ctx`.
+func (rng trueRNG) GetTicketContext(ctx context.Context, ticketId string) (Ticket, error) {
+	ticket := Ticket{}
+	if err := rng.InvokeContext(ctx, "getTicket", GetTicketReq{TicketId: ticketId}, &ticket); err != nil {
+		return Ticket{}, err
+	}
+	return ticket, nil
+}
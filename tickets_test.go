@@ -0,0 +1,80 @@
+package caprice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestTickets(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL), WithRelease(4))
+
+	t.Run("Draws are chained through tickets", func(t *testing.T) {
+		tickets, err := rng.CreateTickets(2, false)
+		if err != nil || len(tickets) != 2 || tickets[0].TicketId == "" || tickets[0].UsedTime != "" {
+			t.Fatalf("got %+v, %v", tickets, err)
+		}
+
+		first, err := rng.GenerateSignedIntegers(6, 1, 49, false, WithTicketID(tickets[0].TicketId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.TicketData == nil || first.TicketData.TicketId != tickets[0].TicketId || first.TicketData.NextTicketId == "" {
+			t.Fatalf("got %+v", first.TicketData)
+		}
+
+		second, err := rng.GenerateSignedUUIDs(1, WithTicketID(first.TicketData.NextTicketId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if second.TicketData.PreviousTicketId != tickets[0].TicketId {
+			t.Errorf("got %+v", second.TicketData)
+		}
+
+		if _, err := rng.GenerateSignedIntegers(6, 1, 49, false, WithTicketID(tickets[0].TicketId)); !errors.As(err, &Error{}) {
+			t.Errorf("reused a ticket: %v", err)
+		}
+
+		heads, _ := rng.ListTickets(HeadTickets)
+		tails, _ := rng.ListTickets(TailTickets)
+		singletons, _ := rng.ListTickets(SingletonTickets)
+		if len(heads) != 1 || heads[0].TicketId != tickets[0].TicketId || len(tails) != 1 || len(singletons) != 1 {
+			t.Errorf("heads %+v, tails %+v, singletons %+v", heads, tails, singletons)
+		}
+
+		ticket, err := rng.GetTicket(tickets[0].TicketId)
+		if err != nil || ticket.Result != nil || ticket.SerialNumber != first.SerialNumber {
+			t.Errorf("an unrevealed ticket showed %+v, %v", ticket, err)
+		}
+		if revealed, err := rng.RevealTickets(second.TicketData.TicketId); err != nil || revealed != 2 {
+			t.Errorf("revealed %d: %v", revealed, err)
+		}
+		ticket, _ = TrueRNG("", WithEndpoint(server.URL), WithRelease(4)).GetTicket(tickets[0].TicketId)
+		if ticket.Result == nil || string(ticket.Result.Raw) != string(first.Raw) {
+			t.Errorf("got %+v", ticket)
+		}
+	})
+
+	t.Run("Tickets need release 4", func(t *testing.T) {
+		old := TrueRNG("key", WithEndpoint(server.URL))
+		if _, err := old.CreateTickets(1, true); !errors.Is(err, ErrUnsupportedRelease) {
+			t.Errorf("got %v", err)
+		}
+		if _, err := old.GenerateSignedIntegers(1, 1, 2, true, WithTicketID("abc")); !errors.Is(err, ErrUnsupportedRelease) {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("Bad requests are refused locally", func(t *testing.T) {
+		if _, err := rng.ListTickets("all"); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("got %v", err)
+		}
+		if _, err := rng.CreateTickets(51, true); !errors.Is(err, ErrParameterOutOfRange) {
+			t.Errorf("got %v", err)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package caprice
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// A UUID as RANDOM.org generates them: version 4, RFC 4122 variant. It marshals to and from its canonical
+// text form, and can be stored in and scanned from a database column holding either that text or the 16
+// raw bytes.
+type UUID [16]byte
+
+var (
+	_ encoding.TextMarshaler   = UUID{}
+	_ encoding.TextUnmarshaler = (*UUID)(nil)
+	_ sql.Scanner              = (*UUID)(nil)
+	_ driver.Valuer            = UUID{}
+)
+
+// A helper function that parses a UUID in canonical (xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx), URN
+// (urn:uuid:...), braced ({...}) or bare 32-digit hex form. Any version is accepted; see IsV4.
+func ParseUUID(text string) (UUID, error) {
+	var uuid UUID
+	trimmed := text
+	switch {
+	case len(trimmed) == 45 && strings.EqualFold(trimmed[:9], "urn:uuid:"):
+		trimmed = trimmed[9:]
+	case len(trimmed) == 38 && trimmed[0] == '{' && trimmed[37] == '}':
+		trimmed = trimmed[1:37]
+	}
+
+	if len(trimmed) == 36 {
+		if trimmed[8] != '-' || trimmed[13] != '-' || trimmed[18] != '-' || trimmed[23] != '-' {
+			return UUID{}, fmt.Errorf("caprice: %q is not a UUID", text)
+		}
+		trimmed = trimmed[:8] + trimmed[9:13] + trimmed[14:18] + trimmed[19:23] + trimmed[24:]
+	}
+	if len(trimmed) != 32 {
+		return UUID{}, fmt.Errorf("caprice: %q is not a UUID", text)
+	}
+	if _, err := hex.Decode(uuid[:], []byte(trimmed)); err != nil {
+		return UUID{}, fmt.Errorf("caprice: %q is not a UUID: %w", text, err)
+	}
+	return uuid, nil
+}
+
+// The version number held in the UUID.
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Whether the UUID is a version 4 UUID of the RFC 4122 variant, as every UUID from RANDOM.org must be.
+func (u UUID) IsV4() bool {
+	return u.Version() == 4 && u[8]&0xc0 == 0x80
+}
+
+// The canonical form, e.g. 1b4e28ba-2fa1-41d2-883f-0016d3cca427.
+func (u UUID) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// The URN form, e.g. urn:uuid:1b4e28ba-2fa1-41d2-883f-0016d3cca427.
+func (u UUID) URN() string {
+	return "urn:uuid:" + u.String()
+}
+
+// The braced form, e.g. {1b4e28ba-2fa1-41d2-883f-0016d3cca427}.
+func (u UUID) Braced() string {
+	return "{" + u.String() + "}"
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// Reads a UUID from a database column holding its text or its 16 raw bytes. A NULL column gives the
+// zero UUID.
+func (u *UUID) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*u = UUID{}
+		return nil
+	case string:
+		return u.UnmarshalText([]byte(src))
+	case []byte:
+		if len(src) == len(u) {
+			copy(u[:], src)
+			return nil
+		}
+		return u.UnmarshalText(src)
+	default:
+		return fmt.Errorf("caprice: cannot scan %T into a UUID", src)
+	}
+}
+
+// Stores the UUID in its canonical text form.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// A helper function that parses the data of a generateUUIDs result, making sure every value is a
+// version 4 UUID.
+func decodeUUIDs(data []interface{}) ([]UUID, error) {
+	uuids := make([]UUID, len(data))
+	for i, value := range data {
+		text, ok := value.(string)
+		if !ok {
+			return []UUID{}, fmt.Errorf("%w: %v is not a UUID", ErrMalformedResponse, value)
+		}
+		uuid, err := ParseUUID(text)
+		if err != nil {
+			return []UUID{}, fmt.Errorf("%w: %w", ErrMalformedResponse, err)
+		}
+		if !uuid.IsV4() {
+			return []UUID{}, fmt.Errorf("%w: %s is not a version 4 UUID", ErrMalformedResponse, text)
+		}
+		uuids[i] = uuid
+	}
+	return uuids, nil
+}
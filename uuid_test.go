@@ -0,0 +1,69 @@
+package caprice
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUUID(t *testing.T) {
+
+	const canonical = "1b4e28ba-2fa1-41d2-883f-0016d3cca427"
+
+	t.Run("Parses and formats every form", func(t *testing.T) {
+		for _, form := range []string{canonical, "urn:uuid:" + canonical, "{" + canonical + "}", "1B4E28BA2FA141D2883F0016D3CCA427"} {
+			uuid, err := ParseUUID(form)
+			if err != nil || uuid.String() != canonical {
+				t.Errorf("%s: got %v, %v", form, uuid, err)
+			}
+		}
+		uuid, _ := ParseUUID(canonical)
+		if uuid.URN() != "urn:uuid:"+canonical || uuid.Braced() != "{"+canonical+"}" || !uuid.IsV4() {
+			t.Errorf("got %s and %s", uuid.URN(), uuid.Braced())
+		}
+		for _, bad := range []string{"", canonical[:35], "1b4e28ba_2fa1_41d2_883f_0016d3cca427", "zb4e28ba-2fa1-41d2-883f-0016d3cca427"} {
+			if _, err := ParseUUID(bad); err == nil {
+				t.Errorf("%q parsed", bad)
+			}
+		}
+	})
+
+	t.Run("Round-trips through JSON and SQL", func(t *testing.T) {
+		uuid, _ := ParseUUID(canonical)
+		encoded, _ := json.Marshal(map[string]UUID{"id": uuid})
+		if string(encoded) != `{"id":"`+canonical+`"}` {
+			t.Errorf("got %s", encoded)
+		}
+		var decoded map[string]UUID
+		if err := json.Unmarshal(encoded, &decoded); err != nil || decoded["id"] != uuid {
+			t.Errorf("got %v, %v", decoded, err)
+		}
+
+		value, _ := uuid.Value()
+		var scanned UUID
+		for _, src := range []interface{}{value, []byte(canonical), uuid[:]} {
+			if err := scanned.Scan(src); err != nil || scanned != uuid {
+				t.Errorf("%v: got %v, %v", src, scanned, err)
+			}
+		}
+		if err := scanned.Scan(42); err == nil {
+			t.Error("scanned an int")
+		}
+		if err := scanned.Scan(nil); err != nil || scanned != (UUID{}) {
+			t.Errorf("NULL: got %v, %v", scanned, err)
+		}
+	})
+
+	t.Run("Rejects UUIDs that are not version 4", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"random":{"data":["1b4e28ba-2fa1-11d2-883f-0016d3cca427"]},"bitsUsed":122,"bitsLeft":1,"requestsLeft":1,"advisoryDelay":0}}`))
+		}))
+		defer server.Close()
+
+		if _, err := TrueRNG("key", WithEndpoint(server.URL)).GenerateUUIDs(1); !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("got %v", err)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package caprice
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Returned when a request is rejected locally, before it is sent, because one of its parameters is
+// outside the limits RANDOM.org enforces. It matches ErrInvalidParameter or ErrParameterOutOfRange,
+// exactly like the error the API would have returned.
+type ParameterError struct {
+	// The parameter at fault, named as in the JSON-RPC request, e.g. "decimalPlaces".
+	Field  string
+	Value  interface{}
+	Reason string
+	kind   error
+}
+
+func (e ParameterError) Error() string {
+	return fmt.Sprintf("caprice: invalid %s %v: %s", e.Field, e.Value, e.Reason)
+}
+
+func (e ParameterError) Is(target error) bool {
+	return target == e.kind
+}
+
+// Implemented by request structs that can be checked before they are sent.
+type validator interface {
+	Validate() error
+}
+
+func outOfRange(field string, value interface{}, format string, args ...interface{}) error {
+	return ParameterError{Field: field, Value: value, Reason: fmt.Sprintf(format, args...), kind: ErrParameterOutOfRange}
+}
+
+func invalid(field string, value interface{}, format string, args ...interface{}) error {
+	return ParameterError{Field: field, Value: value, Reason: fmt.Sprintf(format, args...), kind: ErrInvalidParameter}
+}
+
+func checkN(n, limit int) error {
+	if n < 1 || n > limit {
+		return outOfRange("n", n, "must be between 1 and %d", limit)
+	}
+	return nil
+}
+
+// Checks the request against RANDOM.org's limits for generateIntegers.
+func (r IntegersReq) Validate() error {
+	if err := checkN(r.N, 10000); err != nil {
+		return err
+	}
+	if r.Min < -1e9 || r.Min > 1e9 {
+		return outOfRange("min", r.Min, "must be between -1e9 and 1e9")
+	}
+	if r.Max < -1e9 || r.Max > 1e9 {
+		return outOfRange("max", r.Max, "must be between -1e9 and 1e9")
+	}
+	if r.Min > r.Max {
+		return outOfRange("min", r.Min, "must not be greater than max %d", r.Max)
+	}
+	if !r.Replacement && r.N > r.Max-r.Min+1 {
+		return outOfRange("n", r.N, "exceeds the %d values between min and max, and replacement is false", r.Max-r.Min+1)
+	}
+	switch r.Base {
+	case 0, 2, 8, 10, 16:
+	default:
+		return invalid("base", r.Base, "must be 2, 8, 10 or 16")
+	}
+	return nil
+}
+
+// Checks the request against RANDOM.org's limits for generateIntegerSequences.
+func (r IntegerSequencesReq) Validate() error {
+	if err := checkN(r.N, 1000); err != nil {
+		return err
+	}
+	if len(r.Length) != r.N || len(r.Min) != r.N || len(r.Max) != r.N || len(r.Replacement) != r.N {
+		return invalid("n", r.N, "must match the number of lengths, mins, maxes and replacement flags")
+	}
+	if r.Base != nil && len(r.Base) != r.N {
+		return invalid("base", r.Base, "must hold one base per sequence")
+	}
+
+	total := 0
+	for i := 0; i < r.N; i++ {
+		if r.Length[i] < 1 || r.Length[i] > 10000 {
+			return outOfRange("length", r.Length, "must hold lengths between 1 and 10000")
+		}
+		total += r.Length[i]
+		sequence := IntegersReq{N: r.Length[i], Min: r.Min[i], Max: r.Max[i], Replacement: r.Replacement[i]}
+		if r.Base != nil {
+			sequence.Base = r.Base[i]
+		}
+		if err := sequence.Validate(); err != nil {
+			parameterError := err.(ParameterError)
+			parameterError.Reason = fmt.Sprintf("sequence %d: %s", i, parameterError.Reason)
+			if parameterError.Field == "n" {
+				parameterError.Field = "length"
+			}
+			return parameterError
+		}
+	}
+	if total > 10000 {
+		return outOfRange("length", r.Length, "must not add up to more than 10000")
+	}
+	return nil
+}
+
+// Checks the request against RANDOM.org's limits for generateDecimalFractions.
+func (r DecimalFractionsReq) Validate() error {
+	if err := checkN(r.N, 10000); err != nil {
+		return err
+	}
+	if r.DecimalPlaces < 1 || r.DecimalPlaces > 14 {
+		return outOfRange("decimalPlaces", r.DecimalPlaces, "must be between 1 and 14")
+	}
+	if !r.Replacement && float64(r.N) > math.Pow10(r.DecimalPlaces) {
+		return outOfRange("n", r.N, "exceeds the %v fractions with %d decimal places, and replacement is false",
+			math.Pow10(r.DecimalPlaces), r.DecimalPlaces)
+	}
+	return nil
+}
+
+// Checks the request against RANDOM.org's limits for generateGaussians.
+func (r GaussiansReq) Validate() error {
+	if err := checkN(r.N, 10000); err != nil {
+		return err
+	}
+	if math.Abs(r.Mean) > 1e6 {
+		return outOfRange("mean", r.Mean, "must be between -1e6 and 1e6")
+	}
+	if math.Abs(r.StandardDeviation) > 1e6 {
+		return outOfRange("standardDeviation", r.StandardDeviation, "must be between -1e6 and 1e6")
+	}
+	if r.SignificantDigits < 2 || r.SignificantDigits > 14 {
+		return outOfRange("significantDigits", r.SignificantDigits, "must be between 2 and 14")
+	}
+	return nil
+}
+
+// Checks the request against RANDOM.org's limits for generateStrings.
+func (r StringsReq) Validate() error {
+	if err := checkN(r.N, 10000); err != nil {
+		return err
+	}
+	if r.Length < 1 || r.Length > 32 {
+		return outOfRange("length", r.Length, "must be between 1 and 32")
+	}
+	characters := utf8.RuneCountInString(r.Characters)
+	if characters < 1 || characters > 128 {
+		return outOfRange("characters", fmt.Sprintf("%q", r.Characters), "must hold between 1 and 128 characters")
+	}
+	if !r.Replacement && float64(r.N) > math.Pow(float64(characters), float64(r.Length)) {
+		return outOfRange("n", r.N, "exceeds the possible strings of length %d, and replacement is false", r.Length)
+	}
+	return nil
+}
+
+// Checks the request against RANDOM.org's limits for generateUUIDs.
+func (r UUIDsReq) Validate() error {
+	return checkN(r.N, 1000)
+}
+
+// Checks the request against RANDOM.org's limits for generateBlobs.
+func (r BlobsReq) Validate() error {
+	if err := checkN(r.N, maxBlobs); err != nil {
+		return err
+	}
+	if r.Size < Byte || r.Size > maxBlobBits || r.Size%Byte != 0 {
+		return outOfRange("size", r.Size, "must be a whole number of bytes between 8 and %d bits", maxBlobBits)
+	}
+	if BlobSize(r.N)*r.Size > maxBlobBits {
+		return outOfRange("size", r.Size, "makes %d blobs total %d bits, more than %d", r.N, BlobSize(r.N)*r.Size, maxBlobBits)
+	}
+	if r.Format != "" && r.Format != Base64 && r.Format != Hex {
+		return invalid("format", fmt.Sprintf("%q", r.Format), "must be base64 or hex")
+	}
+	return nil
+}
+
+// Checks the request against RANDOM.org's limits for createTickets.
+func (r CreateTicketsReq) Validate() error {
+	return checkN(r.N, 50)
+}
+
+// Checks that the request asks for a known kind of ticket.
+func (r ListTicketsReq) Validate() error {
+	switch r.TicketType {
+	case SingletonTickets, HeadTickets, TailTickets:
+		return nil
+	}
+	return invalid("ticketType", fmt.Sprintf("%q", r.TicketType), "must be singleton, head or tail")
+}
+
+// Checks the release 4 parameters against RANDOM.org's limits for userData.
+func (p Release4Params) validate() error {
+	if p.UserData == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(p.UserData)
+	if err != nil {
+		return invalid("userData", p.UserData, "cannot be encoded as JSON: %v", err)
+	}
+	if utf8.RuneCount(encoded) > 1000 {
+		return outOfRange("userData", p.UserData, "must encode to at most 1000 characters of JSON, not %d", utf8.RuneCount(encoded))
+	}
+	return nil
+}
+
+// Validates `params` if it knows how.
+func validate(params interface{}) error {
+	if p, ok := params.(interface{ release4() Release4Params }); ok {
+		if err := p.release4().validate(); err != nil {
+			return err
+		}
+	}
+	if v, ok := params.(validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
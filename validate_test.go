@@ -0,0 +1,65 @@
+package caprice
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+
+	cases := []struct {
+		request validator
+		field   string
+		kind    error
+	}{
+		{IntegersReq{N: 10001, Min: 1, Max: 10, Replacement: true}, "n", ErrParameterOutOfRange},
+		{IntegersReq{N: 1, Min: 10, Max: 1}, "min", ErrParameterOutOfRange},
+		{IntegersReq{N: 1, Min: 1, Max: 2e9}, "max", ErrParameterOutOfRange},
+		{IntegersReq{N: 11, Min: 1, Max: 10}, "n", ErrParameterOutOfRange},
+		{DecimalFractionsReq{N: 1, DecimalPlaces: 15}, "decimalPlaces", ErrParameterOutOfRange},
+		{DecimalFractionsReq{N: 11, DecimalPlaces: 1}, "n", ErrParameterOutOfRange},
+		{GaussiansReq{N: 1, Mean: 2e6, SignificantDigits: 4}, "mean", ErrParameterOutOfRange},
+		{GaussiansReq{N: 1, SignificantDigits: 1}, "significantDigits", ErrParameterOutOfRange},
+		{StringsReq{N: 1, Length: 4, Characters: ""}, "characters", ErrParameterOutOfRange},
+		{StringsReq{N: 1, Length: 33, Characters: "ab"}, "length", ErrParameterOutOfRange},
+		{StringsReq{N: 5, Length: 2, Characters: "ab"}, "n", ErrParameterOutOfRange},
+		{UUIDsReq{N: 1001}, "n", ErrParameterOutOfRange},
+		{BlobsReq{N: 1, Size: 12}, "size", ErrParameterOutOfRange},
+		{BlobsReq{N: 2, Size: maxBlobBits}, "size", ErrParameterOutOfRange},
+		{BlobsReq{N: 1, Size: 8, Format: "octal"}, "format", ErrInvalidParameter},
+	}
+
+	for _, c := range cases {
+		var parameterError ParameterError
+		err := c.request.Validate()
+		if !errors.As(err, &parameterError) || parameterError.Field != c.field || !errors.Is(err, c.kind) {
+			t.Errorf("%+v: expected %v naming %s, got %v", c.request, c.kind, c.field, err)
+		}
+	}
+
+	valid := []validator{
+		IntegersReq{N: 10, Min: 1, Max: 10},
+		DecimalFractionsReq{N: 10, DecimalPlaces: 1},
+		GaussiansReq{N: 1, StandardDeviation: 1, SignificantDigits: 2},
+		StringsReq{N: 4, Length: 2, Characters: "ab"},
+		UUIDsReq{N: 1000},
+		BlobsReq{N: 1, Size: maxBlobBits, Format: "hex"},
+	}
+	for _, request := range valid {
+		if err := request.Validate(); err != nil {
+			t.Errorf("%+v: %v", request, err)
+		}
+	}
+
+	t.Run("Bad parameters never reach the network", func(t *testing.T) {
+		counter := &countingTransport{}
+		rng := TrueRNG("key", WithHTTPClient(&http.Client{Transport: counter}))
+		if _, err := rng.GenerateIntegers(1, 10, 1, true); !errors.Is(err, ErrParameterOutOfRange) {
+			t.Errorf("got %v", err)
+		}
+		if counter.requests != 0 {
+			t.Errorf("expected no round trips, saw %d", counter.requests)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package caprice
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Implemented by every Signed*Data: SignedIntegerData, SignedIntegerSequenceData, SignedFloatData,
+// SignedStringData, SignedUUIDData and SignedBlobData. Each carries a `random` object exactly as
+// RANDOM.org sent it, together with the signature over it.
+type SignedData interface {
+	payload() (json.RawMessage, string)
+}
+
+func (d SignedIntegerData) payload() (json.RawMessage, string)         { return d.Raw, d.Signature }
+func (d SignedIntegerSequenceData) payload() (json.RawMessage, string) { return d.Raw, d.Signature }
+func (d SignedFloatData) payload() (json.RawMessage, string)           { return d.Raw, d.Signature }
+func (d SignedStringData) payload() (json.RawMessage, string)          { return d.Raw, d.Signature }
+func (d SignedUUIDData) payload() (json.RawMessage, string)            { return d.Raw, d.Signature }
+func (d SignedBlobData) payload() (json.RawMessage, string)            { return d.Raw, d.Signature }
+
+// Checks signed results locally, without any network call. RANDOM.org signs the SHA-512 digest of the
+// `random` object with RSA (PKCS #1 v1.5), so a Verifier only needs RANDOM.org's public key. Because the
+// check runs over the bytes exactly as received, results can be verified long after they were drawn.
+type Verifier struct {
+	key *rsa.PublicKey
+}
+
+// A helper function that returns a Verifier trusting `key`. Use it for test servers, or to trust a
+// key RANDOM.org rotated to after this package was built.
+func NewVerifier(key *rsa.PublicKey) Verifier {
+	return Verifier{key: key}
+}
+
+// RANDOM.org's certificate for signed results, shipped with the package so that results can be
+// checked without fetching anything, however long after they were drawn.
+//
+//go:embed randomorg.pem
+var randomOrgCertificate []byte
+
+// A helper function that returns a Verifier trusting the RANDOM.org certificate built into this
+// package. It fails with ErrNoPublicKey if the build carries no certificate.
+func DefaultVerifier() (Verifier, error) {
+	if block, _ := pem.Decode(randomOrgCertificate); block == nil {
+		return Verifier{}, fmt.Errorf("%w: no RANDOM.org certificate is built in", ErrNoPublicKey)
+	}
+	key, err := ParsePublicKey(randomOrgCertificate)
+	if err != nil {
+		return Verifier{}, err
+	}
+	return NewVerifier(key), nil
+}
+
+// Parses a PEM encoded RSA public key, either as an X.509 certificate (as RANDOM.org publishes it) or as
+// a bare PKIX or PKCS #1 public key.
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("caprice: no PEM data found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "CERTIFICATE":
+		var certificate *x509.Certificate
+		certificate, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = certificate.PublicKey
+		}
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("caprice: cannot parse public key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("caprice: public key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// Reports whether the signature on `data` is valid. Pass any Signed*Data returned by a signed method.
+func (v Verifier) Verify(data SignedData) (bool, error) {
+	random, signature := data.payload()
+	return v.VerifyRaw(random, signature)
+}
+
+// Reports whether `signature`, base64 encoded as RANDOM.org returns it, is valid for the `random`
+// object. `random` must be byte-for-byte what the server sent; re-encoded JSON will not verify. An
+// invalid signature comes with an error matching ErrBadSignature; a Verifier without a key fails with
+// ErrNoPublicKey.
+func (v Verifier) VerifyRaw(random json.RawMessage, signature string) (bool, error) {
+
+	if v.key == nil {
+		return false, fmt.Errorf("%w: verifier has none", ErrNoPublicKey)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, fmt.Errorf("%w: signature is not valid base64: %w", ErrBadSignature, err)
+	}
+
+	digest := sha512.Sum512(random)
+	if rsa.VerifyPKCS1v15(v.key, crypto.SHA512, digest[:], decoded) != nil {
+		return false, ErrBadSignature
+	}
+	return true, nil
+}
+
+// Check signed results this client fetches again with GetResult against `verifier` rather than
+// DefaultVerifier, e.g. after a key rotation.
+func WithVerifier(verifier Verifier) Option {
+	return func(c *config) {
+		c.verifier = verifier
+	}
+}
@@ -0,0 +1,133 @@
+package caprice
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/AkshatM/caprice/randomtest"
+)
+
+func TestVerifier(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL))
+
+	der, _ := x509.MarshalPKIXPublicKey(server.PublicKey())
+	key, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier(key)
+
+	t.Run("Genuine results verify", func(t *testing.T) {
+		integers, _ := rng.GenerateSignedIntegers(5, 1, 10, true)
+		fractions, _ := rng.GenerateSignedDecimalFractions(5, 4, true)
+		strings, _ := rng.GenerateSignedStrings(5, 8, "ab%⌘<&", true)
+		for _, data := range []SignedData{integers, fractions, strings} {
+			if ok, err := verifier.Verify(data); !ok || err != nil {
+				t.Errorf("%+v did not verify: %v", data, err)
+			}
+		}
+	})
+
+	t.Run("Tampered results do not", func(t *testing.T) {
+		integers, _ := rng.GenerateSignedIntegers(5, 1, 10, true)
+		integers.Raw = append([]byte(" "), integers.Raw...)
+		if ok, _ := verifier.Verify(integers); ok {
+			t.Error("re-encoded data verified")
+		}
+	})
+
+	t.Run("Missing keys are a setup error", func(t *testing.T) {
+		integers, _ := rng.GenerateSignedIntegers(1, 1, 10, true)
+		if _, err := (Verifier{}).Verify(integers); !errors.Is(err, ErrNoPublicKey) {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("The API agrees", func(t *testing.T) {
+		integers, _ := rng.GenerateSignedIntegers(5, 1, 10, true)
+		if ok, err := rng.VerifySignature(integers.Raw, integers.Signature); !ok || err != nil {
+			t.Errorf("verifySignature rejected genuine data: %v", err)
+		}
+	})
+}
+
+func TestDefaultVerifier(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL))
+	// stand the fake server's key in for RANDOM.org's
+	trustBuiltIn(t, server.PublicKey())
+
+	t.Run("Genuine results verify", func(t *testing.T) {
+		verifier, err := DefaultVerifier()
+		if err != nil {
+			t.Fatal(err)
+		}
+		integers, _ := rng.GenerateSignedIntegers(5, 1, 10, true)
+		if ok, err := verifier.Verify(integers); !ok || err != nil {
+			t.Errorf("did not verify: %v", err)
+		}
+	})
+
+	t.Run("Tampered results fail with ErrBadSignature", func(t *testing.T) {
+		verifier, _ := DefaultVerifier()
+		integers, _ := rng.GenerateSignedIntegers(5, 1, 10, true)
+		integers.Raw = append(json.RawMessage(" "), integers.Raw...)
+		if ok, err := verifier.Verify(integers); ok || !errors.Is(err, ErrBadSignature) {
+			t.Errorf("got %v, %v", ok, err)
+		}
+	})
+
+	t.Run("Builds without a certificate say so", func(t *testing.T) {
+		randomOrgCertificate = []byte("no certificate here")
+		if _, err := DefaultVerifier(); !errors.Is(err, ErrNoPublicKey) {
+			t.Errorf("got %v", err)
+		}
+	})
+}
+
+// Makes DefaultVerifier trust `key` instead of the built-in certificate until the test ends.
+func trustBuiltIn(t *testing.T, key *rsa.PublicKey) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtIn := randomOrgCertificate
+	randomOrgCertificate = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	t.Cleanup(func() { randomOrgCertificate = builtIn })
+}
+
+func TestSignedBlobs(t *testing.T) {
+
+	server := randomtest.NewServer()
+	defer server.Close()
+	rng := TrueRNG("key", WithEndpoint(server.URL))
+	for _, format := range []BlobFormat{Base64, Hex} {
+		blobs, err := rng.GenerateSignedBlobs(3, 4*Byte, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(blobs.Data) != 3 || len(blobs.Data[0]) != 4 {
+			t.Errorf("%s: got %v", format, blobs.Data)
+		}
+		if ok, err := NewVerifier(server.PublicKey()).Verify(blobs); !ok || err != nil {
+			t.Errorf("%s: did not verify: %v", format, err)
+		}
+	}
+
+	if _, err := rng.GenerateSignedBlobs(1, 12*Bit, Hex); !errors.Is(err, ErrParameterOutOfRange) {
+		t.Errorf("got %v", err)
+	}
+	if _, err := rng.GenerateSignedBlobs(1, Byte, "octal"); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("got %v", err)
+	}
+}